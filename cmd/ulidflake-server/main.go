@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	ulidflake "github.com/abailinrun/ulid-flake-go/ulidflakescalable"
+)
+
+// timeFormat is RFC3339 with millisecond precision
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+func main() {
+	// Define command-line flags
+	addrFlag := flag.String("addr", ":8080", "Set the address to listen on")
+	epochFlag := flag.String("epoch", "2024-01-01T00:00:00Z", "Set the custom epoch time (e.g., 2024-01-01T00:00:00Z)")
+	entropyFlag := flag.Int("entropy", 1, "Set the custom entropy size (default: 1)")
+	sidFlag := flag.String("sid", "0", "Set the custom scalability ID, or auto to derive it from the host (default: 0)")
+	sidBitsFlag := flag.Int("sid-bits", ulidflake.ScalabilitySize, "Set the scalability ID size in bits, taken from the randomness (5 to 15)")
+	maxBatchFlag := flag.Int("max-batch", defaultMaxBatch, "Set the maximum number of Ulid-Flakes per /ids request")
+	shutdownFlag := flag.Duration("shutdown-timeout", 10*time.Second, "Set how long to wait for requests in flight on shutdown")
+
+	flag.Parse()
+
+	// Set custom configuration if provided
+	var opts []ulidflake.Option
+	if *epochFlag != "" {
+		epochTime, err := time.Parse(time.RFC3339, *epochFlag)
+		if err != nil {
+			log.Fatalf("Invalid epoch time format: %v", err)
+		}
+		opts = append(opts, ulidflake.WithEpochTime(epochTime))
+	}
+	if *entropyFlag != 0 {
+		opts = append(opts, ulidflake.WithEntropySize(*entropyFlag))
+	}
+	if *sidBitsFlag != ulidflake.ScalabilitySize {
+		opts = append(opts, ulidflake.WithSIDBits(*sidBitsFlag))
+	}
+	if *sidFlag == "auto" {
+		opts = append(opts, ulidflake.WithSIDFrom(ulidflake.SIDAuto()))
+	} else if *sidFlag != "0" {
+		sid, err := strconv.ParseInt(*sidFlag, 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid scalability ID: %q is neither an integer nor auto", *sidFlag)
+		}
+		opts = append(opts, ulidflake.WithSID(sid))
+	}
+	if *maxBatchFlag < 1 {
+		log.Fatalf("Invalid maximum batch size: %d", *maxBatchFlag)
+	}
+	g, err := ulidflake.NewGenerator(opts...)
+	if err != nil {
+		log.Fatalf("Failed to set config: %v", err)
+	}
+
+	srv := &http.Server{
+		Addr:              *addrFlag,
+		Handler:           newServer(g, *maxBatchFlag),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	// Shut down gracefully on SIGINT or SIGTERM, letting the requests in flight finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownFlag)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Failed to shut down gracefully: %v", err)
+		}
+	}()
+
+	log.Printf("Serving Ulid-Flakes on %s", *addrFlag)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Failed to serve: %v", err)
+	}
+	log.Printf("Shut down")
+}
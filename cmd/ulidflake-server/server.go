@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	ulidflake "github.com/abailinrun/ulid-flake-go/ulidflakescalable"
+)
+
+const defaultMaxBatch = 1000 // Default maximum number of Ulid-Flakes per /ids request
+
+// server issues and parses Ulid-Flakes over HTTP
+type server struct {
+	generator *ulidflake.Generator
+	maxBatch  int
+	mux       *http.ServeMux
+	metrics   *metrics
+}
+
+// parsed is the response of /parse/{id}
+type parsed struct {
+	ID         string `json:"id"`
+	Int        int64  `json:"int"`
+	Timestamp  int64  `json:"timestamp"`
+	Time       string `json:"time"`
+	Randomness int64  `json:"randomness"`
+	SID        int64  `json:"sid"`
+	Hex        string `json:"hex"`
+	Bin        string `json:"bin"`
+}
+
+// errorResponse is the response of a failed request, with the details of a parse error
+type errorResponse struct {
+	Error  string `json:"error"`
+	Offset *int   `json:"offset,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// newServer creates the handler serving the Ulid-Flakes of the generator
+func newServer(g *ulidflake.Generator, maxBatch int) *server {
+	s := &server{generator: g, maxBatch: maxBatch, mux: http.NewServeMux(), metrics: newMetrics()}
+	s.handle("GET /id", s.handleID)
+	s.handle("GET /ids", s.handleIDs)
+	s.handle("GET /parse/{id}", s.handleParse)
+	s.handle("GET /health", s.handleHealth)
+	s.handle("GET /metrics", s.handleMetrics)
+	return s
+}
+
+// ServeHTTP dispatches the request to the handlers
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handle registers the handler for the pattern, counting its responses
+func (s *server) handle(pattern string, handler http.HandlerFunc) {
+	path := strings.TrimPrefix(pattern, "GET ")
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(rec, r)
+		s.metrics.observe(path, rec.status)
+	})
+}
+
+// handleID issues one Ulid-Flake, waiting out overflows and clock regressions until the request is cancelled
+func (s *server) handleID(w http.ResponseWriter, r *http.Request) {
+	id, err := s.generator.NewIDContext(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusServiceUnavailable, err)
+		return
+	}
+	if wantsText(r) {
+		writeText(w, http.StatusOK, id.String()+"\n")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"id": id.String()})
+}
+
+// handleIDs issues the number of strictly increasing Ulid-Flakes given by n, 1 by default
+func (s *server) handleIDs(w http.ResponseWriter, r *http.Request) {
+	n := 1
+	if value := r.URL.Query().Get("n"); value != "" {
+		var err error
+		n, err = strconv.Atoi(value)
+		if err != nil || n < 1 || n > s.maxBatch {
+			writeError(w, r, http.StatusBadRequest, fmt.Errorf("n must be between 1 and %d", s.maxBatch))
+			return
+		}
+	}
+
+	ids := make([]ulidflake.ID, n)
+	if err := s.generator.FillIDs(ids); err != nil {
+		writeError(w, r, http.StatusServiceUnavailable, err)
+		return
+	}
+	if wantsText(r) {
+		var b strings.Builder
+		for _, id := range ids {
+			b.WriteString(id.String())
+			b.WriteByte('\n')
+		}
+		writeText(w, http.StatusOK, b.String())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]ulidflake.ID{"ids": ids})
+}
+
+// handleParse decodes a Ulid-Flake under the generator's epoch and layout
+func (s *server) handleParse(w http.ResponseWriter, r *http.Request) {
+	id, err := ulidflake.ParseID(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	p := parsed{
+		ID:         id.String(),
+		Int:        id.Int(),
+		Timestamp:  id.Timestamp(),
+		Time:       s.generator.Time(id).Format(timeFormat),
+		Randomness: s.generator.Randomness(id),
+		SID:        s.generator.SID(id),
+		Hex:        id.Hex(),
+		Bin:        id.Bin(),
+	}
+	if wantsText(r) {
+		writeText(w, http.StatusOK, fmt.Sprintf(
+			"Base32:     %s\nInteger:    %d\nTimestamp:  %d\nTime:       %s\nRandomness: %d\nSID:        %d\nHex:        %s\nBin:        %s\n",
+			p.ID, p.Int, p.Timestamp, p.Time, p.Randomness, p.SID, p.Hex, p.Bin))
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// handleHealth reports that the server is serving
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if wantsText(r) {
+		writeText(w, http.StatusOK, "ok\n")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleMetrics writes the generator's counters and the request counts in the Prometheus text format
+func (s *server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.metrics.write(w, s.generator.Stats())
+}
+
+// wantsText reports whether the client asked for plain text with ?format=text or the Accept header
+func wantsText(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "text"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/plain")
+}
+
+// writeJSON writes the value as JSON with the status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeText writes the text with the status
+func writeText(w http.ResponseWriter, status int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(text))
+}
+
+// writeError writes the error with the status, together with the details of a parse error
+func writeError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	resp := errorResponse{Error: err.Error()}
+	var parseErr *ulidflake.ParseError
+	if errors.As(err, &parseErr) {
+		resp.Reason = parseErr.Reason
+		if parseErr.Offset >= 0 {
+			resp.Offset = &parseErr.Offset
+		}
+	}
+	if wantsText(r) {
+		writeText(w, status, resp.Error+"\n")
+		return
+	}
+	writeJSON(w, status, resp)
+}
+
+// statusRecorder records the status written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// requestKey identifies the requests counted together
+type requestKey struct {
+	path   string
+	status int
+}
+
+// metrics counts the requests by path and status
+type metrics struct {
+	mutex    sync.Mutex
+	requests map[requestKey]uint64
+}
+
+// newMetrics creates empty metrics
+func newMetrics() *metrics {
+	return &metrics{requests: map[requestKey]uint64{}}
+}
+
+// observe counts a request
+func (m *metrics) observe(path string, status int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.requests[requestKey{path: path, status: status}]++
+}
+
+// write writes the generator's counters and the request counts in the Prometheus text format
+func (m *metrics) write(w http.ResponseWriter, stats ulidflake.Stats) {
+	counters := []struct {
+		name  string
+		help  string
+		value uint64
+	}{
+		{"ulidflake_generated_total", "Ulid-Flakes generated.", stats.Generated},
+		{"ulidflake_overflows_total", "Randomness exhausted within a millisecond.", stats.Overflows},
+		{"ulidflake_overflow_waits_total", "Overflows resolved by waiting for the next millisecond.", stats.OverflowWaits},
+		{"ulidflake_overflow_borrows_total", "Overflows resolved by borrowing from the next millisecond.", stats.OverflowBorrows},
+		{"ulidflake_regressions_total", "Clock moved backwards behind the last-seen timestamp.", stats.Regressions},
+		{"ulidflake_regression_waits_total", "Regressions resolved by waiting for the clock to catch up.", stats.RegressionWaits},
+		{"ulidflake_regression_reuses_total", "Regressions resolved by reusing the last-seen timestamp.", stats.RegressionReuses},
+	}
+	for _, c := range counters {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.name, c.help, c.name, c.name, c.value)
+	}
+
+	m.mutex.Lock()
+	keys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].path != keys[j].path {
+			return keys[i].path < keys[j].path
+		}
+		return keys[i].status < keys[j].status
+	})
+	fmt.Fprintf(w, "# HELP ulidflake_http_requests_total HTTP requests by path and status code.\n# TYPE ulidflake_http_requests_total counter\n")
+	for _, key := range keys {
+		fmt.Fprintf(w, "ulidflake_http_requests_total{path=%q,code=\"%d\"} %d\n", key.path, key.status, m.requests[key])
+	}
+	m.mutex.Unlock()
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ulidflake "github.com/abailinrun/ulid-flake-go/ulidflakescalable"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestServer creates a server on a generator with the scalability ID 7
+func newTestServer(t *testing.T) *server {
+	t.Helper()
+	g, err := ulidflake.NewGenerator(ulidflake.WithSID(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newServer(g, 10)
+}
+
+// get serves a GET request for the target with the Accept header, if any
+func get(s *server, target, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServer_ID(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := get(s, "/id", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var body struct {
+		ID string `json:"id"`
+	}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	id, err := ulidflake.ParseID(body.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), s.generator.SID(id))
+
+	for _, tt := range []struct {
+		name   string
+		target string
+		accept string
+	}{
+		{name: "format query", target: "/id?format=text"},
+		{name: "accept header", target: "/id", accept: "text/plain"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(s, tt.target, tt.accept)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+			_, err := ulidflake.ParseID(strings.TrimSuffix(rec.Body.String(), "\n"))
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestServer_IDs(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := get(s, "/ids?n=10", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		IDs []ulidflake.ID `json:"ids"`
+	}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Len(t, body.IDs, 10)
+	for i := 1; i < len(body.IDs); i++ {
+		assert.Less(t, body.IDs[i-1], body.IDs[i])
+	}
+
+	rec = get(s, "/ids?format=text", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, strings.Fields(rec.Body.String()), 1)
+
+	rec = get(s, "/ids?n=3&format=text", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, strings.Fields(rec.Body.String()), 3)
+
+	for _, n := range []string{"0", "-1", "11", "ten"} {
+		t.Run("n="+n, func(t *testing.T) {
+			rec := get(s, "/ids?n="+n, "")
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), "n must be between 1 and 10")
+		})
+	}
+}
+
+func TestServer_Parse(t *testing.T) {
+	s := newTestServer(t)
+	u, err := s.generator.FromTime(time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC), ulidflake.WithFixedRandomness(5))
+	assert.Nil(t, err)
+
+	rec := get(s, "/parse/"+u.String(), "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var body parsed
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, parsed{
+		ID:         u.String(),
+		Int:        u.Int(),
+		Timestamp:  u.Timestamp(),
+		Time:       "2024-07-01T12:00:00.000Z",
+		Randomness: 5,
+		SID:        7,
+		Hex:        u.Hex(),
+		Bin:        u.Bin(),
+	}, body)
+
+	rec = get(s, "/parse/"+u.String()+"?format=text", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Time:       2024-07-01T12:00:00.000Z\n")
+	assert.Contains(t, rec.Body.String(), "SID:        7\n")
+}
+
+func TestServer_ParseInvalid(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name       string
+		id         string
+		wantOffset int // -1 if no offset is reported
+	}{
+		{name: "invalid character", id: "00S5TSFMYJHDU", wantOffset: 12},
+		{name: "invalid length", id: "00S5TSFM", wantOffset: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(s, "/parse/"+tt.id, "")
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			var body errorResponse
+			assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.NotEmpty(t, body.Error)
+			assert.NotEmpty(t, body.Reason)
+			if tt.wantOffset < 0 {
+				assert.Nil(t, body.Offset)
+				return
+			}
+			if assert.NotNil(t, body.Offset) {
+				assert.Equal(t, tt.wantOffset, *body.Offset)
+			}
+		})
+	}
+}
+
+func TestServer_Health(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := get(s, "/health", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+
+	rec = get(s, "/health", "text/plain")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok\n", rec.Body.String())
+}
+
+func TestServer_Metrics(t *testing.T) {
+	s := newTestServer(t)
+	get(s, "/id", "")
+	get(s, "/ids?n=3", "")
+	get(s, "/ids?n=0", "")
+
+	rec := get(s, "/metrics", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	body := rec.Body.String()
+	assert.Contains(t, body, "# TYPE ulidflake_generated_total counter\nulidflake_generated_total 4\n")
+	assert.Contains(t, body, "ulidflake_overflows_total 0\n")
+	assert.Contains(t, body, `ulidflake_http_requests_total{path="/id",code="200"} 1`+"\n")
+	assert.Contains(t, body, `ulidflake_http_requests_total{path="/ids",code="200"} 1`+"\n")
+	assert.Contains(t, body, `ulidflake_http_requests_total{path="/ids",code="400"} 1`+"\n")
+}
+
+func TestServer_MethodNotAllowed(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, target := range []string{"/id", "/ids", "/parse/00S5TSFMYJHDT", "/health", "/metrics"} {
+		t.Run(target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, nil))
+			assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		})
+	}
+
+	rec := get(s, "/unknown", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
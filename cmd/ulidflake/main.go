@@ -1,15 +1,20 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	ulidflake "github.com/abailinrun/ulid-flake-go/ulidflake"
 )
 
+// timeFormat is RFC3339 with millisecond precision
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
 func main() {
 	fmt.Println(`
     ██╗░░░██╗██╗░░░░░██╗██████╗░░░░░░░███████╗██╗░░░░░░█████╗░██╗░░██╗███████╗
@@ -48,12 +53,13 @@ func main() {
 	if *generateFlag {
 		ulid, err := ulidflake.New()
 		if err != nil {
-			log.Fatalf("Failed to generate Ulid-Flake: %v", err)
+			fatal("Failed to generate Ulid-Flake", err)
 		}
 		fmt.Printf("Generated Ulid-Flake:\n")
 		fmt.Printf("  Base32:     %s\n", ulid.String())
 		fmt.Printf("  Integer:    %d\n", ulid.Int())
 		fmt.Printf("  Timestamp:  %d\n", ulid.Timestamp())
+		fmt.Printf("  Time:       %s\n", ulid.Time().Format(timeFormat))
 		fmt.Printf("  Randomness: %d\n", ulid.Randomness())
 		fmt.Printf("  Hex:        %s\n", ulid.Hex())
 		fmt.Printf("  Bin:        %s\n", ulid.Bin())
@@ -64,12 +70,13 @@ func main() {
 	if *parseFlag != "" {
 		ulid, err := ulidflake.Parse(*parseFlag)
 		if err != nil {
-			log.Fatalf("Failed to parse Ulid-Flake: %v", err)
+			fatal("Failed to parse Ulid-Flake", err)
 		}
 		fmt.Printf("Parsed Ulid-Flake:\n")
 		fmt.Printf("  Base32:     %s\n", ulid.String())
 		fmt.Printf("  Integer:    %d\n", ulid.Int())
 		fmt.Printf("  Timestamp:  %d\n", ulid.Timestamp())
+		fmt.Printf("  Time:       %s\n", ulid.Time().Format(timeFormat))
 		fmt.Printf("  Randomness: %d\n", ulid.Randomness())
 		fmt.Printf("  Hex:        %s\n", ulid.Hex())
 		fmt.Printf("  Bin:        %s\n", ulid.Bin())
@@ -80,3 +87,30 @@ func main() {
 	flag.Usage()
 	os.Exit(1)
 }
+
+// fatal prints the error together with the details of the structured errors and exits
+func fatal(msg string, err error) {
+	log.Printf("%s: %v", msg, err)
+
+	var parseErr *ulidflake.ParseError
+	var regressionErr *ulidflake.ClockRegressionError
+	var overflowErr *ulidflake.OverflowError
+	switch {
+	case errors.As(err, &parseErr):
+		fmt.Fprintf(os.Stderr, "  Input:      %s\n", parseErr.Input)
+		if parseErr.Offset >= 0 {
+			fmt.Fprintf(os.Stderr, "              %s^\n", strings.Repeat(" ", parseErr.Offset))
+			fmt.Fprintf(os.Stderr, "  Offset:     %d\n", parseErr.Offset)
+		}
+		fmt.Fprintf(os.Stderr, "  Reason:     %s\n", parseErr.Reason)
+	case errors.As(err, &regressionErr):
+		fmt.Fprintf(os.Stderr, "  Previous:   %d\n", regressionErr.Previous)
+		fmt.Fprintf(os.Stderr, "  Current:    %d\n", regressionErr.Current)
+		fmt.Fprintf(os.Stderr, "  Regression: %s\n", regressionErr.Regression())
+	case errors.As(err, &overflowErr):
+		fmt.Fprintf(os.Stderr, "  Field:      %s\n", overflowErr.Field)
+		fmt.Fprintf(os.Stderr, "  Value:      %d\n", overflowErr.Value)
+		fmt.Fprintf(os.Stderr, "  Max:        %d\n", overflowErr.Max)
+	}
+	os.Exit(1)
+}
@@ -1,15 +1,21 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	ulidflake "github.com/abailinrun/ulid-flake-go/ulidflakescalable"
 )
 
+// timeFormat is RFC3339 with millisecond precision
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
 func main() {
 	fmt.Println(`
     ██╗░░░██╗██╗░░░░░██╗██████╗░░░░░░░███████╗██╗░░░░░░█████╗░██╗░░██╗███████╗░░░░░░░██████╗
@@ -25,7 +31,8 @@ func main() {
 	parseFlag := flag.String("parse", "", "Parse a Ulid-Flake string")
 	epochFlag := flag.String("epoch", "2024-01-01T00:00:00Z", "Set the custom epoch time (e.g., 2024-01-01T00:00:00Z)")
 	entropyFlag := flag.Int("entropy", 1, "Set the custom entropy size (default: 1)")
-	sidFlag := flag.Int64("sid", 0, "Set the custom scalability ID (default: 0)")
+	sidFlag := flag.String("sid", "0", "Set the custom scalability ID, or auto to derive it from the host (default: 0)")
+	sidBitsFlag := flag.Int("sid-bits", ulidflake.ScalabilitySize, "Set the scalability ID size in bits, taken from the randomness (5 to 15)")
 
 	flag.Parse()
 
@@ -41,25 +48,36 @@ func main() {
 	if *entropyFlag != 0 {
 		opts = append(opts, ulidflake.WithEntropySize(*entropyFlag))
 	}
-	if *sidFlag != 0 {
-		opts = append(opts, ulidflake.WithSID(*sidFlag))
+	if *sidBitsFlag != ulidflake.ScalabilitySize {
+		opts = append(opts, ulidflake.WithSIDBits(*sidBitsFlag))
 	}
-	if err := ulidflake.SetConfig(opts...); err != nil {
+	if *sidFlag == "auto" {
+		opts = append(opts, ulidflake.WithSIDFrom(ulidflake.SIDAuto()))
+	} else if *sidFlag != "0" {
+		sid, err := strconv.ParseInt(*sidFlag, 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid scalability ID: %q is neither an integer nor auto", *sidFlag)
+		}
+		opts = append(opts, ulidflake.WithSID(sid))
+	}
+	g, err := ulidflake.NewGenerator(opts...)
+	if err != nil {
 		log.Fatalf("Failed to set config: %v", err)
 	}
 
 	// Generate a new Ulid-Flake
 	if *generateFlag {
-		ulid, err := ulidflake.New()
+		ulid, err := g.New()
 		if err != nil {
-			log.Fatalf("Failed to generate Ulid-Flake: %v", err)
+			fatal("Failed to generate Ulid-Flake", err)
 		}
 		fmt.Printf("Generated Ulid-Flake:\n")
 		fmt.Printf("  Base32:     %s\n", ulid.String())
 		fmt.Printf("  Integer:    %d\n", ulid.Int())
 		fmt.Printf("  Timestamp:  %d\n", ulid.Timestamp())
-		fmt.Printf("  Randomness: %d\n", ulid.Randomness())
-		fmt.Printf("  SID:        %d\n", ulid.SID())
+		fmt.Printf("  Time:       %s\n", g.Time(ulid.ID()).Format(timeFormat))
+		fmt.Printf("  Randomness: %d\n", g.Randomness(ulid.ID()))
+		fmt.Printf("  SID:        %d\n", g.SID(ulid.ID()))
 		fmt.Printf("  Hex:        %s\n", ulid.Hex())
 		fmt.Printf("  Bin:        %s\n", ulid.Bin())
 		os.Exit(0)
@@ -69,14 +87,15 @@ func main() {
 	if *parseFlag != "" {
 		ulid, err := ulidflake.Parse(*parseFlag)
 		if err != nil {
-			log.Fatalf("Failed to parse Ulid-Flake: %v", err)
+			fatal("Failed to parse Ulid-Flake", err)
 		}
 		fmt.Printf("Parsed Ulid-Flake:\n")
 		fmt.Printf("  Base32:     %s\n", ulid.String())
 		fmt.Printf("  Integer:    %d\n", ulid.Int())
 		fmt.Printf("  Timestamp:  %d\n", ulid.Timestamp())
-		fmt.Printf("  Randomness: %d\n", ulid.Randomness())
-		fmt.Printf("  SID:        %d\n", ulid.SID())
+		fmt.Printf("  Time:       %s\n", g.Time(ulid.ID()).Format(timeFormat))
+		fmt.Printf("  Randomness: %d\n", g.Randomness(ulid.ID()))
+		fmt.Printf("  SID:        %d\n", g.SID(ulid.ID()))
 		fmt.Printf("  Hex:        %s\n", ulid.Hex())
 		fmt.Printf("  Bin:        %s\n", ulid.Bin())
 		os.Exit(0)
@@ -86,3 +105,30 @@ func main() {
 	flag.Usage()
 	os.Exit(1)
 }
+
+// fatal prints the error together with the details of the structured errors and exits
+func fatal(msg string, err error) {
+	log.Printf("%s: %v", msg, err)
+
+	var parseErr *ulidflake.ParseError
+	var regressionErr *ulidflake.ClockRegressionError
+	var overflowErr *ulidflake.OverflowError
+	switch {
+	case errors.As(err, &parseErr):
+		fmt.Fprintf(os.Stderr, "  Input:      %s\n", parseErr.Input)
+		if parseErr.Offset >= 0 {
+			fmt.Fprintf(os.Stderr, "              %s^\n", strings.Repeat(" ", parseErr.Offset))
+			fmt.Fprintf(os.Stderr, "  Offset:     %d\n", parseErr.Offset)
+		}
+		fmt.Fprintf(os.Stderr, "  Reason:     %s\n", parseErr.Reason)
+	case errors.As(err, &regressionErr):
+		fmt.Fprintf(os.Stderr, "  Previous:   %d\n", regressionErr.Previous)
+		fmt.Fprintf(os.Stderr, "  Current:    %d\n", regressionErr.Current)
+		fmt.Fprintf(os.Stderr, "  Regression: %s\n", regressionErr.Regression())
+	case errors.As(err, &overflowErr):
+		fmt.Fprintf(os.Stderr, "  Field:      %s\n", overflowErr.Field)
+		fmt.Fprintf(os.Stderr, "  Value:      %d\n", overflowErr.Value)
+		fmt.Fprintf(os.Stderr, "  Max:        %d\n", overflowErr.Max)
+	}
+	os.Exit(1)
+}
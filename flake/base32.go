@@ -0,0 +1,84 @@
+package flake
+
+import "fmt"
+
+const (
+	Encoding = "0123456789ABCDEFGHJKMNPQRSTVWXYZ" // Crockford's Base32 encoding characters
+	Len      = 13                                 // Length of the Base32 representation of a Ulid-Flake
+)
+
+// invalidBase32 marks the characters outside of the Base32 decoding tables
+const invalidBase32 = 0xFF
+
+var (
+	strictDecoding  = newDecoding(true)  // Base32 decoding table of the canonical uppercase characters
+	lenientDecoding = newDecoding(false) // Base32 decoding table also accepting lowercase letters and the aliases O, I and L
+)
+
+// newDecoding builds a 256-entry Base32 decoding table indexed by character
+func newDecoding(strict bool) *[256]byte {
+	var table [256]byte
+	for i := range table {
+		table[i] = invalidBase32
+	}
+	for i := 0; i < len(Encoding); i++ {
+		table[Encoding[i]] = byte(i)
+		if !strict {
+			table[Encoding[i]|0x20] = byte(i)
+		}
+	}
+	if !strict {
+		table['O'], table['o'] = 0, 0
+		table['I'], table['i'] = 1, 1
+		table['L'], table['l'] = 1, 1
+	}
+	return &table
+}
+
+// AppendBase32 appends the Base32 representation of the given length to dst
+func AppendBase32(dst []byte, value int64, length int) []byte {
+	n := len(dst)
+	dst = append(dst, make([]byte, length)...)
+	encoded := dst[n:]
+	for i := length - 1; i >= 0; i-- {
+		encoded[i] = Encoding[value&31]
+		value >>= 5
+	}
+	return dst
+}
+
+// DecodeBase32 decodes a Base32 string to a numeric value.
+// Unless strict, lowercase letters and the Crockford aliases O for 0 and I and L for 1 are accepted.
+// A string of the full length must start with a character up to 7, so that the value fits into 63 bits.
+func DecodeBase32[T string | []byte](encoded T, strict bool) (int64, error) {
+	decoding := lenientDecoding
+	if strict {
+		decoding = strictDecoding
+	}
+	var value int64
+	for i := 0; i < len(encoded); i++ {
+		idx := decoding[encoded[i]]
+		if idx == invalidBase32 {
+			reason := fmt.Sprintf("invalid character %q", encoded[i])
+			if lenientDecoding[encoded[i]] != invalidBase32 {
+				reason = fmt.Sprintf("non-canonical character %q in strict mode", encoded[i])
+			}
+			return 0, &ParseError{Input: string(encoded), Offset: i, Reason: reason, Err: ErrInvalidULID}
+		}
+		if i == 0 && len(encoded) == Len && idx > 7 {
+			reason := fmt.Sprintf("leading character %q above '7'", encoded[i])
+			return 0, &ParseError{Input: string(encoded), Offset: i, Reason: reason, Err: ErrOverflow}
+		}
+		value = value<<5 | int64(idx)
+	}
+	return value, nil
+}
+
+// Parse decodes a Base32 string of the full length to a numeric value without allocating
+func Parse[T string | []byte](encoded T, strict bool) (int64, error) {
+	if len(encoded) != Len {
+		reason := fmt.Sprintf("length %d, want %d", len(encoded), Len)
+		return 0, &ParseError{Input: string(encoded), Offset: -1, Reason: reason, Err: ErrInvalidULID}
+	}
+	return DecodeBase32(encoded, strict)
+}
@@ -0,0 +1,83 @@
+package flake
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendBase32(t *testing.T) {
+	tests := []struct {
+		name  string
+		value int64
+		want  string
+	}{
+		{
+			name:  "minimal value",
+			value: 0,
+			want:  "0000000000000",
+		},
+		{
+			name:  "maximal value",
+			value: 1<<Bits - 1,
+			want:  "7ZZZZZZZZZZZZ",
+		},
+		{
+			name:  "regular value",
+			value: 14246757444195114,
+			want:  "00CMXB6TAK4SA",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, "id="+tt.want, string(AppendBase32([]byte("id="), tt.value, Len)))
+
+			got, err := Parse(tt.want, true)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.value, got)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		strict  bool
+		want    int64
+		wantErr error
+	}{
+		{
+			name:  "lowercase and aliases",
+			input: "00cmxb6tak4sa",
+			want:  14246757444195114,
+		},
+		{
+			name:    "lowercase in strict mode",
+			input:   "00cmxb6tak4sa",
+			strict:  true,
+			wantErr: ErrInvalidULID,
+		},
+		{
+			name:    "invalid length",
+			input:   "00CMXB6TAK4S",
+			wantErr: ErrInvalidULID,
+		},
+		{
+			name:    "leading character above 7",
+			input:   "8000000000000",
+			wantErr: ErrOverflow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.input), tt.strict)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package flake
+
+import (
+	"errors"
+	"io"
+)
+
+const batchBufferSize = 256 // Size of the buffer of random bytes read at once for a batch in bytes
+
+// batchReader serves the random bytes of a batch from a buffer refilled in bulk
+type batchReader struct {
+	source io.Reader
+	buffer [batchBufferSize]byte
+	offset int
+}
+
+// Read fills p with the next random bytes, refilling the buffer when it runs out
+func (r *batchReader) Read(p []byte) (int, error) {
+	if r.offset+len(p) > len(r.buffer) {
+		if _, err := io.ReadFull(r.source, r.buffer[:]); err != nil {
+			return 0, err
+		}
+		r.offset = 0
+	}
+	n := copy(p, r.buffer[r.offset:])
+	r.offset += n
+	return n, nil
+}
+
+// Fill generates n strictly increasing Ulid-Flake values while holding the lock, and passes each one to put
+// with its index. The sequence or the randomness is incremented as with NewID, and when it is exhausted
+// the batch rolls into the subsequent milliseconds, which are reserved ahead of the clock
+// up to the maximum drift. Beyond it, the batch waits for the clock instead of failing, whatever the overflow policy.
+func (g *Generator) Fill(n int, put func(i int, value int64)) error {
+	if n < 0 {
+		return ErrInvalidConfig
+	}
+	if n == 0 {
+		return nil
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if err := g.checkLease(); err != nil {
+		return err
+	}
+	timestamp, err := g.currentTimestamp()
+	if err != nil {
+		return err
+	}
+
+	if timestamp < g.previousTimestamp {
+		timestamp, err = g.handleRegression(timestamp)
+		if err != nil {
+			return err
+		}
+	}
+	start := timestamp
+	// Reserve the milliseconds the batch rolled into, even if it fails halfway, from the clock at its end,
+	// so that the clock moving behind it after a wait for a regression or the drift is still a regression
+	defer func() {
+		if g.previousTimestamp <= start {
+			return
+		}
+		if current, err := g.currentTimestamp(); err == nil {
+			g.reservedFrom = current
+			g.reservedUntil = g.previousTimestamp
+		}
+	}()
+
+	reader := &batchReader{source: g.config.EntropySource, offset: batchBufferSize}
+	for i := 0; i < n; i++ {
+		var sequence, randomness int64
+		if timestamp == g.previousTimestamp {
+			sequence, randomness, err = g.increment(reader)
+			if errors.Is(err, ErrOverflow) {
+				timestamp, randomness, err = g.rollOver(timestamp, reader)
+				sequence = 0
+			}
+		} else {
+			randomness, err = generateRandomness(g.config.Layout, reader)
+		}
+		if err != nil {
+			return err
+		}
+		if err := g.reserve(timestamp); err != nil {
+			return err
+		}
+		g.previousTimestamp = timestamp
+		g.previousSequence = sequence
+		g.previousRandomness = randomness
+		g.stats.Generated++
+
+		put(i, g.config.Layout.Pack(timestamp, sequence, randomness, g.config.Node))
+	}
+
+	return nil
+}
+
+// rollOver moves a batch whose sequence or randomness is exhausted into the next millisecond,
+// first waiting for the clock if the next millisecond is beyond the maximum drift ahead of it
+func (g *Generator) rollOver(timestamp int64, source io.Reader) (int64, int64, error) {
+	current, err := g.currentTimestamp()
+	if err != nil {
+		return 0, 0, err
+	}
+	next := timestamp + 1
+	if next > g.config.Layout.MaxTimestamp() {
+		return 0, 0, &OverflowError{Field: "timestamp", Value: next, Max: g.config.Layout.MaxTimestamp()}
+	}
+	maxDrift := g.config.MaxDrift.Milliseconds()
+	if next-current > maxDrift {
+		sleepUntil(g.config.Clock, g.config.Epoch, next-maxDrift)
+		g.stats.Overflows++
+		g.stats.OverflowWaits++
+	}
+	randomness, err := generateRandomness(g.config.Layout, source)
+	return next, randomness, err
+}
@@ -0,0 +1,112 @@
+package flake
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_FillMaxDrift(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy OverflowPolicy
+	}{
+		{
+			name:   "error",
+			policy: OverflowFail,
+		},
+		{
+			name:   "borrow",
+			policy: OverflowBorrow,
+		},
+		{
+			name:   "wait",
+			policy: OverflowWait,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// every Ulid-Flake exhausts the randomness, so each one takes a millisecond
+			g, err := NewGenerator(Config{
+				Layout:         Standard,
+				Epoch:          testEpoch,
+				EntropySize:    MaxEntropySize,
+				OverflowPolicy: tt.policy,
+				MaxDrift:       5 * time.Millisecond,
+				Clock:          NewManualClock(testEpoch.Add(time.Second)),
+				EntropySource:  constantReader(0xFF),
+			})
+			require.Nil(t, err)
+
+			// the batch waits for the clock rather than running further ahead of it than the maximum drift,
+			// whatever the overflow policy
+			for i := 0; i < 5; i++ {
+				require.Nil(t, g.Fill(20, func(i int, value int64) {}))
+				current, _ := g.currentTimestamp()
+				assert.LessOrEqual(t, g.previousTimestamp-current, int64(5))
+			}
+			assert.Equal(t, uint64(100), g.Stats().Generated)
+			assert.Greater(t, g.Stats().OverflowWaits, uint64(0))
+		})
+	}
+}
+
+func TestGenerator_FillSequence(t *testing.T) {
+	snowflake := Must(41, 12, 0, 10)
+	clock := NewManualClock(testEpoch.Add(time.Second))
+	g, err := NewGenerator(Config{Layout: snowflake, Epoch: testEpoch, Node: 3, MaxDrift: DefaultMaxDrift, Clock: clock})
+	require.Nil(t, err)
+
+	// the batch rolls into the subsequent milliseconds once the sequence is exhausted
+	ids := make([]int64, 2*4096+10)
+	require.Nil(t, g.Fill(len(ids), func(i int, value int64) {
+		ids[i] = value
+	}))
+	for i, id := range ids {
+		assert.Equal(t, int64(1000+i/4096), snowflake.Timestamp(id))
+		assert.Equal(t, int64(i%4096), snowflake.Sequence(id))
+		assert.Equal(t, int64(3), snowflake.Node(id))
+	}
+
+	// the clock has not caught up with the reserved milliseconds yet
+	clock.Advance(time.Millisecond)
+	next, err := g.NewID()
+	require.Nil(t, err)
+	assert.Greater(t, next, ids[len(ids)-1])
+
+	assert.ErrorIs(t, g.Fill(-1, func(i int, value int64) {}), ErrInvalidConfig)
+}
+
+func TestGenerator_FillRegressionWait(t *testing.T) {
+	clock := NewManualClock(testEpoch.Add(100 * time.Millisecond))
+	g, err := NewGenerator(Config{
+		Layout:           Standard,
+		Epoch:            testEpoch,
+		EntropySize:      MaxEntropySize,
+		OverflowPolicy:   OverflowWait,
+		RegressionPolicy: RegressionWait,
+		MaxDrift:         DefaultMaxDrift,
+		MaxWait:          DefaultMaxWait,
+		Clock:            clock,
+		EntropySource:    constantReader(0xFF),
+	})
+	require.Nil(t, err)
+	_, err = g.NewID()
+	require.Nil(t, err)
+
+	// the batch waits out the regression, and then rolls ahead of the clock
+	clock.Rewind(4 * time.Millisecond)
+	require.Nil(t, g.Fill(4, func(i int, value int64) {}))
+	assert.Equal(t, int64(104), g.previousTimestamp)
+	assert.Equal(t, Stats{Generated: 5, Regressions: 1, RegressionWaits: 1}, g.Stats())
+
+	// the clock moving behind the time the batch ended at is still a regression
+	clock.Rewind(4 * time.Millisecond)
+	id, err := g.NewID()
+	require.Nil(t, err)
+	assert.Equal(t, int64(105), Standard.Timestamp(id))
+	assert.Equal(t, testEpoch.Add(105*time.Millisecond), clock.Now())
+	assert.Equal(t, Stats{Generated: 6, Overflows: 1, OverflowWaits: 1, Regressions: 2, RegressionWaits: 2}, g.Stats())
+}
@@ -0,0 +1,186 @@
+package flake
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultBufferedSize = 1024                   // Default number of Ulid-Flakes kept ahead by a BufferedGenerator
+	DefaultStaleAfter   = 100 * time.Millisecond // Default age after which a buffered Ulid-Flake is discarded
+)
+
+const refillBackoff = time.Millisecond // Pause of the refill after a failed generation
+
+// BufferedGenerator serves Ulid-Flakes pre-generated by a background goroutine,
+// so that they are available without reading the clock and entropy source on the critical path.
+// Buffered Ulid-Flakes older than the stale threshold are discarded, so that they still approximate
+// the time they are handed out at. The staleness is judged against the generator's clock, epoch
+// and layout at creation. A BufferedGenerator is safe for concurrent use by multiple goroutines.
+type BufferedGenerator struct {
+	generator  *Generator
+	clock      Clock
+	epoch      time.Time
+	layout     Layout
+	staleAfter time.Duration
+	results    chan int64
+	failures   chan error
+	ctx        context.Context
+	cancel     context.CancelFunc
+	done       chan struct{}
+	discarded  atomic.Uint64
+}
+
+// BufferedOption defines the type for functional options of a BufferedGenerator
+type BufferedOption func(*bufferedConfig) error
+
+type bufferedConfig struct {
+	size       int
+	staleAfter time.Duration
+}
+
+// NewBufferedGenerator creates a new BufferedGenerator refilled from the generator until it is closed
+// or the context is done. Overflows are waited out in the background, while other generation errors are
+// returned to the callers waiting on an empty buffer as they occur, and are never buffered.
+func NewBufferedGenerator(ctx context.Context, g *Generator, opts ...BufferedOption) (*BufferedGenerator, error) {
+	if g == nil {
+		return nil, ErrInvalidConfig
+	}
+	cfg := &bufferedConfig{
+		size:       DefaultBufferedSize,
+		staleAfter: DefaultStaleAfter,
+	}
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	generatorConfig := g.Config()
+	ctx, cancel := context.WithCancel(ctx)
+	b := &BufferedGenerator{
+		generator:  g,
+		clock:      generatorConfig.Clock,
+		epoch:      generatorConfig.Epoch,
+		layout:     generatorConfig.Layout,
+		staleAfter: cfg.staleAfter,
+		results:    make(chan int64, cfg.size),
+		failures:   make(chan error),
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+	go b.refill()
+	return b, nil
+}
+
+// refill keeps the buffer full until the context is done
+func (b *BufferedGenerator) refill() {
+	defer close(b.done)
+	defer close(b.results)
+
+	for {
+		id, err := b.generator.NewID()
+		if err == nil {
+			select {
+			case b.results <- id:
+			case <-b.ctx.Done():
+				return
+			}
+			continue
+		}
+		if !errors.Is(err, ErrOverflow) {
+			// Hand the error to a caller waiting on the empty buffer, if any, and retry after the backoff
+			select {
+			case b.failures <- err:
+			default:
+			}
+		}
+		select {
+		case <-time.After(refillBackoff):
+		case <-b.ctx.Done():
+			return
+		}
+	}
+}
+
+// NewID returns a new Ulid-Flake value from the buffer, waiting for the refill if it is empty.
+// While waiting, it returns the error of a failed refill. It returns ErrClosed once the generator
+// is closed or its context is done.
+func (b *BufferedGenerator) NewID() (int64, error) {
+	return b.NewIDContext(context.Background())
+}
+
+// NewIDContext returns a new Ulid-Flake value from the buffer as NewID does, but waits for the refill
+// only until the context is done, and then returns the context's error.
+func (b *BufferedGenerator) NewIDContext(ctx context.Context) (int64, error) {
+	for {
+		if b.ctx.Err() != nil {
+			return 0, ErrClosed
+		}
+		select {
+		case <-b.ctx.Done():
+			return 0, ErrClosed
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case id, ok := <-b.results:
+			if !ok {
+				return 0, ErrClosed
+			}
+			if b.isStale(id) {
+				b.discarded.Add(1)
+				continue
+			}
+			return id, nil
+		case err := <-b.failures:
+			return 0, err
+		}
+	}
+}
+
+// isStale reports whether the buffered value is older than the stale threshold, without taking the generator's lock
+func (b *BufferedGenerator) isStale(id int64) bool {
+	generated := b.epoch.Add(time.Duration(b.layout.Timestamp(id)) * time.Millisecond)
+	return b.clock.Now().Sub(generated) > b.staleAfter
+}
+
+// Len returns the number of Ulid-Flakes currently buffered
+func (b *BufferedGenerator) Len() int {
+	return len(b.results)
+}
+
+// Discarded returns the number of buffered Ulid-Flakes discarded as stale
+func (b *BufferedGenerator) Discarded() uint64 {
+	return b.discarded.Load()
+}
+
+// Close stops the refill and waits for the background goroutine to exit
+func (b *BufferedGenerator) Close() error {
+	b.cancel()
+	<-b.done
+	return nil
+}
+
+// WithBufferSize sets the number of Ulid-Flakes kept ahead
+func WithBufferSize(size int) BufferedOption {
+	return func(cfg *bufferedConfig) error {
+		if size <= 0 {
+			return ErrInvalidConfig
+		}
+		cfg.size = size
+		return nil
+	}
+}
+
+// WithStaleAfter sets the age after which a buffered Ulid-Flake is discarded
+func WithStaleAfter(d time.Duration) BufferedOption {
+	return func(cfg *bufferedConfig) error {
+		if d <= 0 {
+			return ErrInvalidConfig
+		}
+		cfg.staleAfter = d
+		return nil
+	}
+}
@@ -0,0 +1,136 @@
+package flake
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBufferedGenerator_Layout(t *testing.T) {
+	snowflake := Must(41, 12, 0, 10)
+	g, err := NewGenerator(Config{Layout: snowflake, Epoch: testEpoch, Node: 5, OverflowPolicy: OverflowWait, MaxWait: DefaultMaxWait})
+	require.Nil(t, err)
+	b, err := NewBufferedGenerator(context.Background(), g, WithBufferSize(16))
+	require.Nil(t, err)
+	defer b.Close()
+
+	previous := int64(-1)
+	for i := 0; i < 100; i++ {
+		id, err := b.NewID()
+		require.Nil(t, err)
+		assert.Greater(t, id, previous)
+		assert.Equal(t, int64(5), snowflake.Node(id))
+		previous = id
+	}
+}
+
+func TestBufferedGenerator_StaleWithoutLock(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 6, 6, 6, 6, 6, 0, time.UTC))
+	g, err := NewGenerator(Config{Layout: Standard, Epoch: testEpoch, OverflowPolicy: OverflowWait, Clock: clock})
+	assert.Nil(t, err)
+	b, err := NewBufferedGenerator(context.Background(), g, WithStaleAfter(10*time.Millisecond))
+	assert.Nil(t, err)
+	defer b.Close()
+
+	id, err := b.NewID()
+	assert.Nil(t, err)
+
+	// The refill holds the generator's lock in a tight loop, so the staleness check must not take it
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	assert.False(t, b.isStale(id))
+	clock.Advance(11 * time.Millisecond)
+	assert.True(t, b.isStale(id))
+}
+
+// failingOnceReader fails its first read and then reads from the underlying reader
+type failingOnceReader struct {
+	failed atomic.Bool
+	reader io.Reader
+}
+
+func (r *failingOnceReader) Read(p []byte) (int, error) {
+	if !r.failed.Swap(true) {
+		return 0, errors.New("entropy exhausted")
+	}
+	return r.reader.Read(p)
+}
+
+func TestBufferedGenerator_Recovery(t *testing.T) {
+	g, err := NewGenerator(Config{
+		Layout:         Standard,
+		Epoch:          testEpoch,
+		OverflowPolicy: OverflowWait,
+		MaxWait:        DefaultMaxWait,
+		EntropySource:  &failingOnceReader{reader: NewSeededSource(1)},
+	})
+	require.Nil(t, err)
+	b, err := NewBufferedGenerator(context.Background(), g, WithBufferSize(8), WithStaleAfter(time.Hour))
+	require.Nil(t, err)
+	defer b.Close()
+
+	// the refill recovers from the failure, which is not handed out once the buffer is refilled
+	require.Eventually(t, func() bool { return b.Len() == 8 }, time.Second, time.Millisecond)
+	previous := int64(-1)
+	for i := 0; i < 100; i++ {
+		id, err := b.NewID()
+		require.Nil(t, err)
+		assert.Greater(t, id, previous)
+		previous = id
+	}
+}
+
+func TestBufferedGenerator_Failure(t *testing.T) {
+	g, err := NewGenerator(Config{Layout: Standard, Epoch: testEpoch, EntropySource: failingReader{}})
+	require.Nil(t, err)
+	b, err := NewBufferedGenerator(context.Background(), g)
+	require.Nil(t, err)
+	defer b.Close()
+
+	// the failures of the refill are returned to the waiting callers as they occur
+	for i := 0; i < 3; i++ {
+		_, err = b.NewID()
+		assert.EqualError(t, err, "entropy exhausted")
+	}
+	assert.Zero(t, b.Len())
+}
+
+// blockingReader blocks every read until it is released
+type blockingReader struct {
+	release chan struct{}
+}
+
+func (r blockingReader) Read(p []byte) (int, error) {
+	<-r.release
+	for i := range p {
+		p[i] = 1
+	}
+	return len(p), nil
+}
+
+func TestBufferedGenerator_NewIDContext(t *testing.T) {
+	reader := blockingReader{release: make(chan struct{})}
+	g, err := NewGenerator(Config{Layout: Standard, Epoch: testEpoch, EntropySource: reader})
+	require.Nil(t, err)
+	b, err := NewBufferedGenerator(context.Background(), g, WithStaleAfter(time.Hour))
+	require.Nil(t, err)
+
+	// the caller stops waiting on the empty buffer once its context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = b.NewIDContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(reader.release)
+	_, err = b.NewIDContext(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, b.Close())
+	_, err = b.NewIDContext(context.Background())
+	assert.ErrorIs(t, err, ErrClosed)
+}
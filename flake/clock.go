@@ -0,0 +1,134 @@
+package flake
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const contextWaitStep = 10 * time.Millisecond // Longest sleep between checks of the context in SleepContext
+
+// Clock provides the current time to a generator and lets it wait for the time to pass
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// SystemClock reads the wall clock of the system
+type SystemClock struct{}
+
+// Now returns the current wall clock time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep pauses the current goroutine for the given duration
+func (SystemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// MonotonicClock reads the wall clock once and then advances with the monotonic clock,
+// so that it never moves backwards when the wall clock is stepped
+type MonotonicClock struct {
+	start time.Time
+}
+
+// NewMonotonicClock creates a new MonotonicClock anchored at the current wall clock time
+func NewMonotonicClock() *MonotonicClock {
+	return &MonotonicClock{start: time.Now()}
+}
+
+// Now returns the anchored wall clock time advanced by the elapsed monotonic time
+func (c *MonotonicClock) Now() time.Time {
+	return c.start.Round(0).Add(time.Since(c.start))
+}
+
+// Sleep pauses the current goroutine for the given duration
+func (c *MonotonicClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// ManualClock is a clock that only moves when told to, for deterministic testing.
+// Sleep advances the clock by the given duration unless the clock is frozen.
+type ManualClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	frozen bool
+}
+
+// NewManualClock creates a new ManualClock set to the given time
+func NewManualClock(t time.Time) *ManualClock {
+	return &ManualClock{now: t}
+}
+
+// Now returns the current time of the clock
+func (c *ManualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+// Sleep advances the clock by the given duration, or does nothing when the clock is frozen
+func (c *ManualClock) Sleep(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.frozen && d > 0 {
+		c.now = c.now.Add(d)
+	}
+}
+
+// Set sets the clock to the given time
+func (c *ManualClock) Set(t time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = t
+}
+
+// Advance moves the clock forward by the given duration
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// Rewind moves the clock backward by the given duration
+func (c *ManualClock) Rewind(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(-d)
+}
+
+// Freeze stops Sleep from advancing the clock
+func (c *ManualClock) Freeze() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.frozen = true
+}
+
+// Unfreeze lets Sleep advance the clock again
+func (c *ManualClock) Unfreeze() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.frozen = false
+}
+
+// SleepContext sleeps on the clock for the duration in steps of at most 10ms,
+// so that a long wait returns soon after the context is done with the context's error
+func SleepContext(ctx context.Context, clock Clock, d time.Duration) error {
+	for d > 0 {
+		step := min(d, contextWaitStep)
+		clock.Sleep(step)
+		d -= step
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,75 @@
+package flake
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemClock(t *testing.T) {
+	clock := SystemClock{}
+	before := time.Now()
+	got := clock.Now()
+	assert.False(t, got.Before(before))
+
+	clock.Sleep(time.Millisecond)
+	assert.True(t, clock.Now().After(got))
+}
+
+func TestMonotonicClock(t *testing.T) {
+	before := time.Now()
+	clock := NewMonotonicClock()
+	got := clock.Now()
+	assert.False(t, got.Before(before.Round(0)))
+
+	clock.Sleep(time.Millisecond)
+	assert.GreaterOrEqual(t, clock.Now().Sub(got), time.Millisecond)
+}
+
+func TestManualClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	assert.Equal(t, start, clock.Now())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), clock.Now())
+
+	clock.Rewind(2 * time.Second)
+	assert.Equal(t, start.Add(-time.Second), clock.Now())
+
+	clock.Sleep(time.Second)
+	assert.Equal(t, start, clock.Now())
+
+	clock.Freeze()
+	clock.Sleep(time.Second)
+	assert.Equal(t, start, clock.Now())
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, start.Add(time.Millisecond), clock.Now())
+
+	clock.Unfreeze()
+	clock.Sleep(time.Millisecond)
+	assert.Equal(t, start.Add(2*time.Millisecond), clock.Now())
+
+	clock.Set(start)
+	assert.Equal(t, start, clock.Now())
+}
+
+func TestSleepContext(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	assert.Nil(t, SleepContext(context.Background(), clock, 25*time.Millisecond))
+	assert.Equal(t, start.Add(25*time.Millisecond), clock.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, SleepContext(ctx, clock, time.Hour), context.Canceled)
+	assert.Equal(t, start.Add(35*time.Millisecond), clock.Now())
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	before := time.Now()
+	assert.ErrorIs(t, SleepContext(ctx, SystemClock{}, time.Hour), context.Canceled)
+	assert.Less(t, time.Since(before), time.Second)
+}
@@ -0,0 +1,235 @@
+package flake
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConcurrentGenerator generates Ulid-Flakes of any layout without a mutex, by atomically swapping the last
+// generated Ulid-Flake, which packs the timestamp, the sequence and the randomness of the monotonic state.
+// It scales with the number of goroutines generating concurrently, and still guarantees that the
+// Ulid-Flakes are strictly increasing in the order they are generated within the process.
+// Its configuration is fixed at creation, and its entropy source must be safe for concurrent use,
+// as all the sources of this package are.
+type ConcurrentGenerator struct {
+	state        atomic.Int64
+	config       Config
+	borrowed     atomic.Pointer[borrowing]
+	pending      atomic.Int32
+	highWater    atomic.Int64
+	reserveMutex sync.Mutex
+	stats        concurrentStats
+}
+
+// borrowing is a range of milliseconds borrowed ahead of the clock, from the clock's timestamp to the borrowed one
+type borrowing struct {
+	from  int64
+	until int64
+}
+
+// concurrentStats holds the counters of a ConcurrentGenerator
+type concurrentStats struct {
+	generated        atomic.Uint64
+	overflows        atomic.Uint64
+	overflowWaits    atomic.Uint64
+	overflowBorrows  atomic.Uint64
+	regressions      atomic.Uint64
+	regressionWaits  atomic.Uint64
+	regressionReuses atomic.Uint64
+}
+
+// NewConcurrentGenerator creates a new ConcurrentGenerator from the configuration,
+// loading the high-water mark of its state store
+func NewConcurrentGenerator(cfg Config) (*ConcurrentGenerator, error) {
+	if err := cfg.prepare(); err != nil {
+		return nil, err
+	}
+	mark, err := cfg.loadMark()
+	if err != nil {
+		return nil, err
+	}
+	g := &ConcurrentGenerator{config: cfg}
+	if cfg.StateStore != nil {
+		g.restoreState(mark)
+	}
+	return g, nil
+}
+
+// Config returns the configuration of the generator
+func (g *ConcurrentGenerator) Config() Config {
+	return g.config
+}
+
+// NewID generates a new Ulid-Flake value.
+// It follows the overflow and regression policies of the generator as Generator.NewID does.
+func (g *ConcurrentGenerator) NewID() (int64, error) {
+	if g.config.Lease != nil {
+		if err := g.config.Lease.Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	layout := g.config.Layout
+	var waited time.Duration
+	var overflowWaited, regressionWaited bool
+	for {
+		previous := g.state.Load()
+		previousTimestamp := layout.Timestamp(previous)
+
+		timestamp, err := generateTimestamp(layout, g.config.Clock.Now(), g.config.Epoch)
+		if err != nil {
+			return 0, err
+		}
+
+		var next int64
+		var borrowed, reused bool
+		if timestamp > previousTimestamp {
+			randomness, err := generateRandomness(layout, g.config.EntropySource)
+			if err != nil {
+				return 0, err
+			}
+			next = g.pack(timestamp, 0, randomness)
+		} else {
+			// a borrowed timestamp is swapped in before its range is recorded, so the pending borrowings are
+			// loaded before the range, and waited for instead of failing
+			pending := g.pending.Load() > 0
+			if timestamp < previousTimestamp && !g.isBorrowed(timestamp, previousTimestamp) {
+				if pending {
+					runtime.Gosched()
+					continue
+				}
+				switch g.config.RegressionPolicy {
+				case RegressionWait:
+					if previousTimestamp-timestamp > g.config.MaxWait.Milliseconds() || waited > g.config.MaxWait {
+						g.stats.regressions.Add(1)
+						return 0, &ClockRegressionError{Previous: previousTimestamp, Current: timestamp}
+					}
+					waited += sleepUntil(g.config.Clock, g.config.Epoch, previousTimestamp)
+					regressionWaited = true
+					continue
+				case RegressionReuse:
+					reused = true
+				default:
+					g.stats.regressions.Add(1)
+					return 0, &ClockRegressionError{Previous: previousTimestamp, Current: timestamp}
+				}
+			}
+
+			nextTimestamp := previousTimestamp
+			sequence, randomness, err := increment(layout, g.config.EntropySize,
+				layout.Sequence(previous), layout.Randomness(previous), g.config.EntropySource)
+			if errors.Is(err, ErrOverflow) {
+				switch g.config.OverflowPolicy {
+				case OverflowWait:
+					slept, ok := sleepWithin(g.config.Clock, g.config.Epoch, previousTimestamp+1, g.config.MaxWait-waited)
+					if !ok {
+						g.stats.overflows.Add(1)
+						return 0, err
+					}
+					waited += slept
+					overflowWaited = true
+					continue
+				case OverflowBorrow:
+					nextTimestamp++
+					if nextTimestamp > layout.MaxTimestamp() {
+						g.stats.overflows.Add(1)
+						return 0, &OverflowError{Field: "timestamp", Value: nextTimestamp, Max: layout.MaxTimestamp()}
+					}
+					if nextTimestamp-timestamp > g.config.MaxDrift.Milliseconds() {
+						g.stats.overflows.Add(1)
+						return 0, err
+					}
+					borrowed = true
+					sequence = 0
+					randomness, err = generateRandomness(layout, g.config.EntropySource)
+				default:
+					g.stats.overflows.Add(1)
+					return 0, err
+				}
+			}
+			if err != nil {
+				return 0, err
+			}
+			next = g.pack(nextTimestamp, sequence, randomness)
+		}
+
+		if err := g.reserve(layout.Timestamp(next)); err != nil {
+			return 0, err
+		}
+		if borrowed {
+			g.pending.Add(1)
+		}
+		if !g.state.CompareAndSwap(previous, next) {
+			if borrowed {
+				g.pending.Add(-1)
+			}
+			continue
+		}
+		if borrowed {
+			g.recordBorrowing(timestamp, layout.Timestamp(next))
+			g.pending.Add(-1)
+		}
+
+		g.stats.generated.Add(1)
+		if overflowWaited {
+			g.stats.overflows.Add(1)
+			g.stats.overflowWaits.Add(1)
+		}
+		if borrowed {
+			g.stats.overflows.Add(1)
+			g.stats.overflowBorrows.Add(1)
+		}
+		if regressionWaited {
+			g.stats.regressions.Add(1)
+			g.stats.regressionWaits.Add(1)
+		}
+		if reused {
+			g.stats.regressions.Add(1)
+			g.stats.regressionReuses.Add(1)
+		}
+		return next, nil
+	}
+}
+
+// pack packs the timestamp, the sequence, the randomness and the generator's node into a Ulid-Flake value
+func (g *ConcurrentGenerator) pack(timestamp, sequence, randomness int64) int64 {
+	return g.config.Layout.Pack(timestamp, sequence, randomness, g.config.Node)
+}
+
+// isBorrowed reports whether the previous timestamp was borrowed ahead of the given one by the borrow policy.
+// Only the milliseconds actually borrowed count, so that a clock moving backwards is still a regression.
+func (g *ConcurrentGenerator) isBorrowed(timestamp, previousTimestamp int64) bool {
+	b := g.borrowed.Load()
+	return b != nil && timestamp >= b.from && previousTimestamp <= b.until
+}
+
+// recordBorrowing records the borrowed range unless a later one is already recorded. It is recorded once the
+// borrowed timestamp is swapped in, and the goroutines seeing it before then wait for the range instead of failing.
+func (g *ConcurrentGenerator) recordBorrowing(from, until int64) {
+	next := &borrowing{from: from, until: until}
+	for {
+		b := g.borrowed.Load()
+		if b != nil && b.until >= until {
+			return
+		}
+		if g.borrowed.CompareAndSwap(b, next) {
+			return
+		}
+	}
+}
+
+// Stats returns a snapshot of the generator's counters
+func (g *ConcurrentGenerator) Stats() Stats {
+	return Stats{
+		Generated:        g.stats.generated.Load(),
+		Overflows:        g.stats.overflows.Load(),
+		OverflowWaits:    g.stats.overflowWaits.Load(),
+		OverflowBorrows:  g.stats.overflowBorrows.Load(),
+		Regressions:      g.stats.regressions.Load(),
+		RegressionWaits:  g.stats.regressionWaits.Load(),
+		RegressionReuses: g.stats.regressionReuses.Load(),
+	}
+}
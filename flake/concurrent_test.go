@@ -0,0 +1,178 @@
+package flake
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentGenerator_Sequence(t *testing.T) {
+	snowflake := Must(41, 12, 0, 10)
+	clock := NewManualClock(testEpoch.Add(time.Second))
+	g, err := NewConcurrentGenerator(Config{Layout: snowflake, Epoch: testEpoch, Node: 7, Clock: clock})
+	require.Nil(t, err)
+
+	for i := int64(0); i <= snowflake.MaxSequence(); i++ {
+		id, err := g.NewID()
+		require.Nil(t, err)
+		assert.Equal(t, int64(1000), snowflake.Timestamp(id))
+		assert.Equal(t, i, snowflake.Sequence(id))
+		assert.Equal(t, int64(7), snowflake.Node(id))
+	}
+
+	_, err = g.NewID()
+	assert.Equal(t, &OverflowError{Field: "sequence", Value: 4096, Max: 4095}, err)
+	assert.Equal(t, Stats{Generated: 4096, Overflows: 1}, g.Stats())
+}
+
+func TestConcurrentGenerator_OverflowWaitWithoutMaxWait(t *testing.T) {
+	snowflake := Must(41, 12, 0, 10)
+	clock := NewManualClock(testEpoch.Add(time.Second))
+	g, err := NewConcurrentGenerator(Config{Layout: snowflake, Epoch: testEpoch, OverflowPolicy: OverflowWait, Clock: clock})
+	require.Nil(t, err)
+	for i := int64(0); i <= snowflake.MaxSequence(); i++ {
+		_, err := g.NewID()
+		require.Nil(t, err)
+	}
+
+	// a zero maximum wait fails without sleeping
+	_, err = g.NewID()
+	assert.ErrorIs(t, err, ErrOverflow)
+	assert.Equal(t, testEpoch.Add(time.Second), clock.Now())
+	assert.Equal(t, Stats{Generated: 4096, Overflows: 1}, g.Stats())
+}
+
+func TestConcurrentGenerator_OverflowBorrowRegression(t *testing.T) {
+	snowflake := Must(41, 12, 0, 10)
+	clock := NewManualClock(testEpoch.Add(time.Second))
+	g, err := NewConcurrentGenerator(Config{
+		Layout:         snowflake,
+		Epoch:          testEpoch,
+		OverflowPolicy: OverflowBorrow,
+		MaxDrift:       DefaultMaxDrift,
+		Clock:          clock,
+	})
+	require.Nil(t, err)
+
+	// a clock moving backwards within the maximum drift is still a regression when nothing was borrowed
+	_, err = g.NewID()
+	require.Nil(t, err)
+	clock.Rewind(time.Millisecond)
+	_, err = g.NewID()
+	assert.Equal(t, &ClockRegressionError{Previous: 1000, Current: 999}, err)
+	assert.Equal(t, Stats{Generated: 1, Regressions: 1}, g.Stats())
+
+	// and so it is behind the clock a millisecond was borrowed at
+	clock.Advance(time.Millisecond)
+	var borrowed int64
+	for i := int64(0); i <= snowflake.MaxSequence(); i++ {
+		borrowed, err = g.NewID()
+		require.Nil(t, err)
+	}
+	assert.Equal(t, int64(1001), snowflake.Timestamp(borrowed))
+	assert.Equal(t, &borrowing{from: 1000, until: 1001}, g.borrowed.Load())
+	assert.Equal(t, int32(0), g.pending.Load())
+	clock.Rewind(time.Millisecond)
+	_, err = g.NewID()
+	assert.Equal(t, &ClockRegressionError{Previous: 1001, Current: 999}, err)
+
+	// while the clock the millisecond was borrowed at still reuses it
+	clock.Advance(time.Millisecond)
+	got, err := g.NewID()
+	require.Nil(t, err)
+	assert.Equal(t, int64(1001), snowflake.Timestamp(got))
+	assert.Greater(t, got, borrowed)
+	assert.Equal(t, Stats{Generated: 4098, Overflows: 1, OverflowBorrows: 1, Regressions: 2}, g.Stats())
+}
+
+func TestConcurrentGenerator_Layouts(t *testing.T) {
+	const goroutines, perGoroutine = 8, 1000
+	for _, layout := range []Layout{Standard, Scalable, Must(41, 12, 0, 10), Must(41, 10, 12, 0)} {
+		t.Run(layout.String(), func(t *testing.T) {
+			g, err := NewConcurrentGenerator(Config{
+				Layout:         layout,
+				Epoch:          testEpoch,
+				Node:           layout.MaxNode(),
+				OverflowPolicy: OverflowWait,
+				MaxWait:        DefaultMaxWait,
+			})
+			require.Nil(t, err)
+
+			results := make([][]int64, goroutines)
+			var wg sync.WaitGroup
+			for i := 0; i < goroutines; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					for j := 0; j < perGoroutine; j++ {
+						id, err := g.NewID()
+						if err != nil {
+							t.Error(err)
+							return
+						}
+						results[i] = append(results[i], id)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			seen := make(map[int64]bool, goroutines*perGoroutine)
+			for _, ids := range results {
+				for j, id := range ids {
+					assert.False(t, seen[id])
+					seen[id] = true
+					assert.Equal(t, layout.MaxNode(), layout.Node(id))
+					if j > 0 {
+						assert.Greater(t, id, ids[j-1])
+					}
+				}
+			}
+			assert.Len(t, seen, goroutines*perGoroutine)
+		})
+	}
+}
+
+func TestConcurrentGenerator_Allocs(t *testing.T) {
+	layout := Must(41, 10, 12, 0)
+	g, err := NewConcurrentGenerator(Config{
+		Layout:        layout,
+		Epoch:         testEpoch,
+		Clock:         NewManualClock(testEpoch.Add(time.Second)),
+		EntropySource: NewSeededSource(1),
+	})
+	require.Nil(t, err)
+
+	// the random bytes are read into a buffer on the stack
+	allocs := testing.AllocsPerRun(int(layout.MaxSequence()/2), func() {
+		if _, err := g.NewID(); err != nil {
+			t.Error(err)
+		}
+	})
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkParallel(b *testing.B) {
+	for _, layout := range []Layout{Standard, Must(41, 12, 0, 10)} {
+		b.Run(layout.String(), func(b *testing.B) {
+			g, err := NewConcurrentGenerator(Config{
+				Layout:         layout,
+				Epoch:          testEpoch,
+				OverflowPolicy: OverflowWait,
+				MaxWait:        DefaultMaxWait,
+				EntropySource:  runtimeReader{},
+			})
+			require.Nil(b, err)
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := g.NewID(); err != nil {
+						b.Error(err)
+					}
+				}
+			})
+		})
+	}
+}
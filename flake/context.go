@@ -0,0 +1,56 @@
+package flake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// NewIDContext generates a new Ulid-Flake value. When the sequence or the randomness overflows within a millisecond
+// or the clock moved backwards, it waits for the next millisecond or for the clock to catch up instead
+// of failing, until the context is done. The error is then wrapped together with the context's error.
+// It returns without waiting if the wait would exceed the context's deadline, as measured by the generator's clock.
+func (g *Generator) NewIDContext(ctx context.Context) (int64, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		g.mutex.Lock()
+		id, err := g.newID()
+		if err == nil {
+			g.mutex.Unlock()
+			return id, nil
+		}
+		retry, ok := g.retryTimestamp(err)
+		deadline := g.config.Epoch.Add(time.Duration(retry) * time.Millisecond)
+		clock := g.config.Clock
+		g.mutex.Unlock()
+
+		if !ok {
+			return 0, err
+		}
+		wait := deadline.Sub(clock.Now())
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Sub(clock.Now()) < wait {
+			return 0, fmt.Errorf("%w: %w", err, context.DeadlineExceeded)
+		}
+		if ctxErr := SleepContext(ctx, clock, wait); ctxErr != nil {
+			return 0, fmt.Errorf("%w: %w", err, ctxErr)
+		}
+	}
+}
+
+// retryTimestamp returns the timestamp to wait for before retrying after an error,
+// and whether the error is recoverable by waiting
+func (g *Generator) retryTimestamp(err error) (int64, bool) {
+	var regressionErr *ClockRegressionError
+	if errors.As(err, &regressionErr) {
+		return regressionErr.Previous, true
+	}
+	var overflowErr *OverflowError
+	if errors.As(err, &overflowErr) && (overflowErr.Field == "sequence" || overflowErr.Field == "randomness") {
+		return g.previousTimestamp + 1, true
+	}
+	return 0, false
+}
@@ -0,0 +1,68 @@
+package flake
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_NewIDContextSequence(t *testing.T) {
+	snowflake := Must(41, 12, 0, 10)
+	clock := NewManualClock(testEpoch.Add(time.Second))
+	g, err := NewGenerator(Config{Layout: snowflake, Epoch: testEpoch, Clock: clock})
+	require.Nil(t, err)
+	require.Nil(t, g.Fill(4096, func(i int, value int64) {}))
+
+	// the sequence is exhausted, so the generator waits for the next millisecond
+	id, err := g.NewIDContext(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, int64(1001), snowflake.Timestamp(id))
+	assert.Equal(t, int64(0), snowflake.Sequence(id))
+	assert.Equal(t, testEpoch.Add(1001*time.Millisecond), clock.Now())
+}
+
+func TestGenerator_NewIDContextDeadline(t *testing.T) {
+	tests := []struct {
+		name    string
+		offset  time.Duration
+		timeout time.Duration
+		wantErr error
+	}{
+		{
+			name:    "clock before the deadline",
+			offset:  -time.Hour,
+			timeout: time.Second,
+		},
+		{
+			name:    "clock past the deadline",
+			offset:  time.Hour,
+			timeout: time.Minute,
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewManualClock(time.Now().Add(tt.offset))
+			g, err := NewGenerator(Config{Layout: Standard, Epoch: testEpoch, Clock: clock})
+			require.Nil(t, err)
+			previous, err := g.NewID()
+			require.Nil(t, err)
+			clock.Rewind(2 * time.Second)
+
+			// the wait for the clock to catch up is compared with the deadline on the generator's clock
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			id, err := g.NewIDContext(ctx)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.ErrorIs(t, err, ErrInvalidTimestamp)
+				return
+			}
+			require.Nil(t, err)
+			assert.Greater(t, id, previous)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package flake
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	mathrand "math/rand/v2"
+	"sync"
+)
+
+const DefaultBufferSize = 256 // Default size of the buffer of a buffered entropy source in bytes
+
+// EntropySource provides the random bytes for the randomness and entropy of a generator
+type EntropySource interface {
+	io.Reader
+}
+
+// cryptoSource reads from crypto/rand
+type cryptoSource struct{}
+
+// NewCryptoSource creates an unpredictable EntropySource reading from crypto/rand
+func NewCryptoSource() EntropySource {
+	return cryptoSource{}
+}
+
+// Read fills p with random bytes from crypto/rand
+func (cryptoSource) Read(p []byte) (int, error) {
+	return rand.Read(p)
+}
+
+// bufferedSource serves random bytes from a buffer refilled in bulk
+type bufferedSource struct {
+	mutex  sync.Mutex
+	buffer []byte
+	offset int
+	fill   func(p []byte) error
+}
+
+// newBufferedSource creates a bufferedSource of the given size, refilled with the fill function
+func newBufferedSource(size int, fill func(p []byte) error) *bufferedSource {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	buffer := make([]byte, size)
+	return &bufferedSource{buffer: buffer, offset: size, fill: fill}
+}
+
+// Read fills p from the buffer, refilling the buffer whenever it runs out
+func (s *bufferedSource) Read(p []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	n := 0
+	for n < len(p) {
+		if s.offset == len(s.buffer) {
+			if err := s.fill(s.buffer); err != nil {
+				return n, err
+			}
+			s.offset = 0
+		}
+		copied := copy(p[n:], s.buffer[s.offset:])
+		s.offset += copied
+		n += copied
+	}
+	return n, nil
+}
+
+// NewBufferedSource creates an EntropySource that reads from r in chunks of the given size,
+// amortizing the cost of the underlying reads across many Ulid-Flakes
+func NewBufferedSource(r io.Reader, size int) EntropySource {
+	return newBufferedSource(size, func(p []byte) error {
+		_, err := io.ReadFull(r, p)
+		return err
+	})
+}
+
+// NewSeededSource creates a deterministic EntropySource from the given seed, for tests and golden files.
+// It is predictable and must not be used where unpredictability matters.
+func NewSeededSource(seed uint64) EntropySource {
+	pcg := mathrand.NewPCG(seed, seed)
+	return newBufferedSource(DefaultBufferSize, func(p []byte) error {
+		fillUint64s(p, pcg.Uint64)
+		return nil
+	})
+}
+
+// NewChaCha8Source creates a fast buffered EntropySource backed by the ChaCha8 generator
+func NewChaCha8Source(seed [32]byte) EntropySource {
+	chacha := mathrand.NewChaCha8(seed)
+	return newBufferedSource(DefaultBufferSize, func(p []byte) error {
+		fillUint64s(p, chacha.Uint64)
+		return nil
+	})
+}
+
+// entropyBuffers holds the buffers read into from the entropy sources outside this package
+var entropyBuffers = sync.Pool{
+	New: func() any {
+		return new([8]byte)
+	},
+}
+
+// readEntropy fills p, of at most 8 bytes, from the source. The readers of this package are called directly,
+// and the other sources read into a pooled buffer, so that p can be on the caller's stack without escaping.
+func readEntropy(source io.Reader, p []byte) error {
+	switch s := source.(type) {
+	case *bufferedSource:
+		_, err := s.Read(p)
+		return err
+	case *batchReader:
+		_, err := s.Read(p)
+		return err
+	}
+	buffer := entropyBuffers.Get().(*[8]byte)
+	defer entropyBuffers.Put(buffer)
+	rnd := buffer[:len(p)]
+	if _, err := io.ReadFull(source, rnd); err != nil {
+		return err
+	}
+	copy(p, rnd)
+	return nil
+}
+
+// fillUint64s fills p with the bytes of successive values of next
+func fillUint64s(p []byte, next func() uint64) {
+	var b [8]byte
+	for i := 0; i < len(p); i += len(b) {
+		binary.BigEndian.PutUint64(b[:], next())
+		copy(p[i:], b[:])
+	}
+}
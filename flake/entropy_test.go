@@ -0,0 +1,141 @@
+package flake
+
+import (
+	"bytes"
+	"errors"
+	mathrand "math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingReader counts the reads made to the underlying reader
+type countingReader struct {
+	reads int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.reads++
+	for i := range p {
+		p[i] = byte(i)
+	}
+	return len(p), nil
+}
+
+// runtimeReader fills every read from the runtime's per-thread generator, without taking a lock
+type runtimeReader struct{}
+
+func (runtimeReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(mathrand.Uint32())
+	}
+	return len(p), nil
+}
+
+// failingReader always fails
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("entropy exhausted")
+}
+
+func TestNewCryptoSource(t *testing.T) {
+	source := NewCryptoSource()
+	b := make([]byte, 32)
+	n, err := source.Read(b)
+	assert.Nil(t, err)
+	assert.Equal(t, len(b), n)
+	assert.NotEqual(t, make([]byte, 32), b)
+}
+
+func TestNewBufferedSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		readSize  int
+		reads     int
+		wantReads int
+	}{
+		{
+			name:      "single refill",
+			size:      16,
+			readSize:  3,
+			reads:     5,
+			wantReads: 1,
+		},
+		{
+			name:      "refill when the buffer runs out",
+			size:      16,
+			readSize:  3,
+			reads:     6,
+			wantReads: 2,
+		},
+		{
+			name:      "default size",
+			size:      0,
+			readSize:  1,
+			reads:     DefaultBufferSize,
+			wantReads: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &countingReader{}
+			source := NewBufferedSource(r, tt.size)
+			var got []byte
+			for i := 0; i < tt.reads; i++ {
+				b := make([]byte, tt.readSize)
+				n, err := source.Read(b)
+				assert.Nil(t, err)
+				assert.Equal(t, tt.readSize, n)
+				got = append(got, b...)
+			}
+			assert.Equal(t, tt.wantReads, r.reads)
+
+			want := make([]byte, 0, len(got))
+			for len(want) < len(got) {
+				size := tt.size
+				if size == 0 {
+					size = DefaultBufferSize
+				}
+				for i := 0; i < size; i++ {
+					want = append(want, byte(i))
+				}
+			}
+			assert.Equal(t, want[:len(got)], got)
+		})
+	}
+}
+
+func TestNewBufferedSource_Error(t *testing.T) {
+	source := NewBufferedSource(failingReader{}, 16)
+	_, err := source.Read(make([]byte, 3))
+	assert.NotNil(t, err)
+}
+
+func TestNewSeededSource(t *testing.T) {
+	a := make([]byte, 300)
+	b := make([]byte, 300)
+	_, err := NewSeededSource(42).Read(a)
+	assert.Nil(t, err)
+	_, err = NewSeededSource(42).Read(b)
+	assert.Nil(t, err)
+	assert.Equal(t, a, b)
+
+	c := make([]byte, 300)
+	_, err = NewSeededSource(43).Read(c)
+	assert.Nil(t, err)
+	assert.NotEqual(t, a, c)
+}
+
+func TestNewChaCha8Source(t *testing.T) {
+	seed := [32]byte{1, 2, 3}
+	a := make([]byte, 300)
+	b := make([]byte, 300)
+	_, err := NewChaCha8Source(seed).Read(a)
+	assert.Nil(t, err)
+	_, err = NewChaCha8Source(seed).Read(b)
+	assert.Nil(t, err)
+	assert.Equal(t, a, b)
+	assert.False(t, bytes.Equal(make([]byte, 300), a))
+}
@@ -0,0 +1,79 @@
+package flake
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrOverflow         = errors.New("overflow error")
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+	ErrInvalidULID      = errors.New("invalid ULID")
+	ErrInvalidLayout    = errors.New("invalid layout")
+	ErrInvalidConfig    = errors.New("invalid configuration")
+	ErrClosed           = errors.New("generator closed")
+)
+
+// ParseError describes why a Ulid-Flake string could not be parsed.
+// It matches ErrInvalidULID, or ErrOverflow if the string encodes a value beyond 63 bits, with errors.Is.
+type ParseError struct {
+	Input  string // String being parsed
+	Offset int    // Byte offset of the offending character, or -1 if the string as a whole is invalid
+	Reason string // Description of the problem
+	Err    error  // Sentinel error matched by errors.Is
+}
+
+// Error returns the description of the parse error
+func (e *ParseError) Error() string {
+	if e.Offset < 0 {
+		return fmt.Sprintf("%v: parsing %q: %s", e.Err, e.Input, e.Reason)
+	}
+	return fmt.Sprintf("%v: parsing %q: %s at offset %d", e.Err, e.Input, e.Reason, e.Offset)
+}
+
+// Unwrap returns the sentinel error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ClockRegressionError describes a clock that moved backwards behind the last-seen timestamp.
+// It matches ErrInvalidTimestamp with errors.Is.
+type ClockRegressionError struct {
+	Previous int64 // Last-seen timestamp in milliseconds since the epoch
+	Current  int64 // Current timestamp in milliseconds since the epoch
+}
+
+// Regression returns how far the clock moved backwards
+func (e *ClockRegressionError) Regression() time.Duration {
+	return time.Duration(e.Previous-e.Current) * time.Millisecond
+}
+
+// Error returns the description of the clock regression
+func (e *ClockRegressionError) Error() string {
+	return fmt.Sprintf("%v: clock moved backwards by %v (previous timestamp %d, current timestamp %d)",
+		ErrInvalidTimestamp, e.Regression(), e.Previous, e.Current)
+}
+
+// Unwrap returns ErrInvalidTimestamp
+func (e *ClockRegressionError) Unwrap() error {
+	return ErrInvalidTimestamp
+}
+
+// OverflowError describes a component that does not fit into its bits.
+// It matches ErrOverflow with errors.Is.
+type OverflowError struct {
+	Field string // Overflowing component: "timestamp", "sequence", "randomness" or "value"
+	Value int64  // Value that does not fit
+	Max   int64  // Maximum value of the component
+}
+
+// Error returns the description of the overflow
+func (e *OverflowError) Error() string {
+	return fmt.Sprintf("%v: %s %d out of range [0, %d]", ErrOverflow, e.Field, e.Value, e.Max)
+}
+
+// Unwrap returns ErrOverflow
+func (e *OverflowError) Unwrap() error {
+	return ErrOverflow
+}
@@ -0,0 +1,425 @@
+package flake
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	MinEntropySize = 1 // Minimum entropy size (1 byte)
+	MaxEntropySize = 3 // Maximum entropy size (3 byte)
+)
+
+// Lease reports whether a generator may still issue Ulid-Flakes with its node, e.g. a leased scalability ID
+type Lease interface {
+	// Err returns a non-nil error once the lease is lost or closed, or nil while it is held
+	Err() error
+}
+
+// Config is the configuration of a generator. The unset Clock, EntropySource, EntropySize and ReserveAhead
+// take their default values, while a zero MaxDrift or MaxWait neither borrows nor waits.
+type Config struct {
+	Layout           Layout           // Bit layout of the generated Ulid-Flakes
+	Epoch            time.Time        // Time of the zero timestamp
+	Node             int64            // Node (scalability ID) packed into the node field
+	EntropySize      int              // Number of bytes of the entropy incrementing the randomness within a millisecond
+	OverflowPolicy   OverflowPolicy   // Behavior when the sequence or the randomness is exhausted within a millisecond
+	MaxDrift         time.Duration    // Maximum drift of a borrowed timestamp ahead of the clock
+	RegressionPolicy RegressionPolicy // Behavior when the clock moves backwards
+	MaxWait          time.Duration    // Maximum wait for the clock to catch up or to reach the next millisecond
+	Clock            Clock            // Clock reading the current time
+	EntropySource    EntropySource    // Source of the random bytes
+	Lease            Lease            // Lease of the node, checked before each generation, optional
+	StateStore       StateStore       // Store of the high-water mark, optional
+	ReserveAhead     time.Duration    // Time the high-water mark is reserved ahead of the generated timestamps
+}
+
+// prepare sets the defaults of the unset fields and validates the configuration
+func (cfg *Config) prepare() error {
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock{}
+	}
+	if cfg.EntropySource == nil {
+		cfg.EntropySource = NewCryptoSource()
+	}
+	if cfg.EntropySize == 0 {
+		cfg.EntropySize = MinEntropySize
+	}
+	if cfg.ReserveAhead == 0 {
+		cfg.ReserveAhead = DefaultReserveAhead
+	}
+
+	if err := cfg.Layout.Validate(); err != nil {
+		return err
+	}
+	if cfg.Node < 0 || cfg.Node > cfg.Layout.MaxNode() {
+		return fmt.Errorf("%w: node %d out of range [0, %d]", ErrInvalidConfig, cfg.Node, cfg.Layout.MaxNode())
+	}
+	if cfg.EntropySize < MinEntropySize || cfg.EntropySize > MaxEntropySize {
+		return fmt.Errorf("%w: entropy size %d out of range [%d, %d]", ErrInvalidConfig, cfg.EntropySize, MinEntropySize, MaxEntropySize)
+	}
+	if cfg.OverflowPolicy < OverflowFail || cfg.OverflowPolicy > OverflowBorrow ||
+		cfg.RegressionPolicy < RegressionFail || cfg.RegressionPolicy > RegressionReuse {
+		return fmt.Errorf("%w: unknown policy", ErrInvalidConfig)
+	}
+	if cfg.MaxDrift < 0 || cfg.MaxWait < 0 || cfg.ReserveAhead < time.Millisecond {
+		return fmt.Errorf("%w: negative duration", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// loadMark returns the persisted high-water mark of the configuration's state store, or the zero time without one.
+// A mark at most ReserveAhead ahead of the clock was left by a restart within the reserved window, which is waited
+// out on the clock, so that the first Ulid-Flakes are not refused as a regression. A mark further ahead means
+// that the clock moved backwards, and is left to the regression policy.
+func (cfg *Config) loadMark() (time.Time, error) {
+	if cfg.StateStore == nil {
+		return time.Time{}, nil
+	}
+	mark, err := cfg.StateStore.Load()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if wait := mark.Sub(cfg.Clock.Now()); !mark.IsZero() && wait > 0 && wait <= cfg.ReserveAhead {
+		cfg.Clock.Sleep(wait)
+	}
+	return mark, nil
+}
+
+// Generator generates Ulid-Flakes of any layout with its own configuration and monotonic state.
+// Within a millisecond, it increments the sequence and draws a new randomness if the layout has a sequence,
+// and otherwise increments the randomness by a non-zero entropy.
+// A Generator is safe for concurrent use by multiple goroutines.
+type Generator struct {
+	mutex              sync.Mutex
+	previousTimestamp  int64
+	previousSequence   int64
+	previousRandomness int64
+	reservedFrom       int64
+	reservedUntil      int64
+	config             Config
+	stats              Stats
+	highWater          int64
+}
+
+// NewGenerator creates a new Generator from the configuration, loading the high-water mark of its state store
+func NewGenerator(cfg Config) (*Generator, error) {
+	if err := cfg.prepare(); err != nil {
+		return nil, err
+	}
+	mark, err := cfg.loadMark()
+	if err != nil {
+		return nil, err
+	}
+	g := &Generator{}
+	g.apply(cfg, mark)
+	return g, nil
+}
+
+// apply sets the configuration of the generator and resumes from the high-water mark of its state store
+func (g *Generator) apply(cfg Config, mark time.Time) {
+	g.config = cfg
+	if cfg.StateStore != nil {
+		g.restoreState(mark)
+	}
+}
+
+// SetConfig replaces the configuration of the generator while keeping its monotonic state
+func (g *Generator) SetConfig(cfg Config) error {
+	if err := cfg.prepare(); err != nil {
+		return err
+	}
+	mark, err := cfg.loadMark()
+	if err != nil {
+		return err
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.apply(cfg, mark)
+
+	return nil
+}
+
+// Config returns the configuration of the generator
+func (g *Generator) Config() Config {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return g.config
+}
+
+// Stats returns a snapshot of the generator's counters
+func (g *Generator) Stats() Stats {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return g.stats
+}
+
+// NewID generates a new Ulid-Flake value
+func (g *Generator) NewID() (int64, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return g.newID()
+}
+
+// NewRandomness generates a randomness value within the layout from the generator's entropy source,
+// without changing the monotonic state
+func (g *Generator) NewRandomness() (int64, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return generateRandomness(g.config.Layout, g.config.EntropySource)
+}
+
+// newID generates a new Ulid-Flake value while the lock is held
+func (g *Generator) newID() (int64, error) {
+	if err := g.checkLease(); err != nil {
+		return 0, err
+	}
+	timestamp, err := g.currentTimestamp()
+	if err != nil {
+		return 0, err
+	}
+
+	var sequence, randomness int64
+	if timestamp < g.previousTimestamp {
+		timestamp, err = g.handleRegression(timestamp)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if timestamp == g.previousTimestamp {
+		sequence, randomness, err = g.increment(g.config.EntropySource)
+		if errors.Is(err, ErrOverflow) {
+			g.stats.Overflows++
+			timestamp, randomness, err = g.handleOverflow(timestamp, err)
+			sequence = 0
+		}
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		randomness, err = generateRandomness(g.config.Layout, g.config.EntropySource)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err := g.reserve(timestamp); err != nil {
+		return 0, err
+	}
+	g.previousTimestamp = timestamp
+	g.previousSequence = sequence
+	g.previousRandomness = randomness
+	g.stats.Generated++
+
+	return g.config.Layout.Pack(timestamp, sequence, randomness, g.config.Node), nil
+}
+
+// checkLease returns the error of the generator's lease once it is lost
+func (g *Generator) checkLease() error {
+	if g.config.Lease == nil {
+		return nil
+	}
+	return g.config.Lease.Err()
+}
+
+// currentTimestamp generates a timestamp from the generator's clock
+func (g *Generator) currentTimestamp() (int64, error) {
+	return generateTimestamp(g.config.Layout, g.config.Clock.Now(), g.config.Epoch)
+}
+
+// increment returns the sequence and the randomness following the previous ones within the same millisecond
+func (g *Generator) increment(source io.Reader) (int64, int64, error) {
+	return increment(g.config.Layout, g.config.EntropySize, g.previousSequence, g.previousRandomness, source)
+}
+
+// isBorrowed reports whether the previous timestamp was borrowed ahead of the given one,
+// either by the borrow policy or by a batch reserving the milliseconds ahead of the clock.
+// Only the milliseconds actually borrowed count, so that a clock moving backwards is still a regression.
+func (g *Generator) isBorrowed(timestamp int64) bool {
+	return timestamp >= g.reservedFrom && g.previousTimestamp <= g.reservedUntil
+}
+
+// handleOverflow resolves an exhausted sequence or randomness according to the overflow policy,
+// or returns the overflow error if it cannot be resolved
+func (g *Generator) handleOverflow(timestamp int64, overflow error) (int64, int64, error) {
+	switch g.config.OverflowPolicy {
+	case OverflowWait:
+		var waited time.Duration
+		next := timestamp
+		for next <= timestamp {
+			slept, ok := sleepWithin(g.config.Clock, g.config.Epoch, timestamp+1, g.config.MaxWait-waited)
+			if !ok {
+				return 0, 0, overflow
+			}
+			waited += slept
+			var err error
+			next, err = g.currentTimestamp()
+			if err != nil {
+				return 0, 0, err
+			}
+		}
+		g.stats.OverflowWaits++
+		randomness, err := generateRandomness(g.config.Layout, g.config.EntropySource)
+		return next, randomness, err
+	case OverflowBorrow:
+		current, err := g.currentTimestamp()
+		if err != nil {
+			return 0, 0, err
+		}
+		next := timestamp + 1
+		if next > g.config.Layout.MaxTimestamp() {
+			return 0, 0, &OverflowError{Field: "timestamp", Value: next, Max: g.config.Layout.MaxTimestamp()}
+		}
+		if next-current > g.config.MaxDrift.Milliseconds() {
+			return 0, 0, overflow
+		}
+		g.stats.OverflowBorrows++
+		g.reservedFrom = current
+		g.reservedUntil = next
+		randomness, err := generateRandomness(g.config.Layout, g.config.EntropySource)
+		return next, randomness, err
+	default:
+		return 0, 0, overflow
+	}
+}
+
+// handleRegression resolves a timestamp behind the previous one according to the regression policy
+func (g *Generator) handleRegression(timestamp int64) (int64, error) {
+	if g.isBorrowed(timestamp) {
+		return g.previousTimestamp, nil
+	}
+	g.stats.Regressions++
+
+	switch g.config.RegressionPolicy {
+	case RegressionWait:
+		var waited time.Duration
+		for timestamp < g.previousTimestamp {
+			if g.previousTimestamp-timestamp > g.config.MaxWait.Milliseconds() || waited > g.config.MaxWait {
+				return 0, &ClockRegressionError{Previous: g.previousTimestamp, Current: timestamp}
+			}
+			waited += sleepUntil(g.config.Clock, g.config.Epoch, g.previousTimestamp)
+			var err error
+			timestamp, err = g.currentTimestamp()
+			if err != nil {
+				return 0, err
+			}
+		}
+		g.stats.RegressionWaits++
+		return timestamp, nil
+	case RegressionReuse:
+		g.stats.RegressionReuses++
+		return g.previousTimestamp, nil
+	default:
+		return 0, &ClockRegressionError{Previous: g.previousTimestamp, Current: timestamp}
+	}
+}
+
+// generateTimestamp generates a timestamp of the layout from the time against the epoch
+func generateTimestamp(l Layout, now time.Time, epoch time.Time) (int64, error) {
+	timestamp := now.Sub(epoch).Milliseconds()
+	if timestamp < 0 || timestamp > l.MaxTimestamp() {
+		return 0, &OverflowError{Field: "timestamp", Value: timestamp, Max: l.MaxTimestamp()}
+	}
+	return timestamp, nil
+}
+
+// generateRandomness generates a randomness value within the layout from the bytes read from the source
+func generateRandomness(l Layout, source io.Reader) (int64, error) {
+	size := (l.RandomnessBits + 7) / 8
+	if size == 0 {
+		return 0, nil
+	}
+	var buffer [8]byte
+	rnd := buffer[:size]
+	if err := readEntropy(source, rnd); err != nil {
+		return 0, err
+	}
+	var randomness int64
+	for _, b := range rnd {
+		randomness = randomness<<8 | int64(b)
+	}
+	return randomness & l.MaxRandomness(), nil
+}
+
+// generateEntropy generates an entropy value of the given size to increment the randomness
+func generateEntropy(size int, source io.Reader) (int64, error) {
+	if size < MinEntropySize || size > MaxEntropySize {
+		return 0, fmt.Errorf("%w: entropy size %d out of range [%d, %d]", ErrInvalidConfig, size, MinEntropySize, MaxEntropySize)
+	}
+	var buffer [MaxEntropySize]byte
+	rnd := buffer[:size]
+	if err := readEntropy(source, rnd); err != nil {
+		return 0, err
+	}
+	var entropy int64
+	for _, b := range rnd {
+		entropy = (entropy << 8) | int64(b)
+	}
+	return entropy, nil
+}
+
+// increment returns the sequence and the randomness following the given ones within the same millisecond.
+// With a sequence, the sequence is incremented and a new randomness is drawn, and otherwise
+// the randomness is incremented by a non-zero entropy of the given size. A randomness narrower than that of
+// the Scalable layout masks the entropy to a 128th of it, so that it still leaves room for many increments,
+// and with fewer than 9 randomness bits, the randomness is incremented by 1.
+func increment(l Layout, entropySize int, sequence, randomness int64, source io.Reader) (int64, int64, error) {
+	if l.SequenceBits > 0 {
+		sequence++
+		if sequence > l.MaxSequence() {
+			return 0, 0, &OverflowError{Field: "sequence", Value: sequence, Max: l.MaxSequence()}
+		}
+		randomness, err := generateRandomness(l, source)
+		return sequence, randomness, err
+	}
+
+	mask := int64(math.MaxInt64)
+	if l.RandomnessBits < Scalable.RandomnessBits {
+		mask = l.MaxRandomness() >> 7
+	}
+	entropy := int64(1)
+	if mask > 1 {
+		entropy = 0
+		for entropy <= 0 {
+			var err error
+			entropy, err = generateEntropy(entropySize, source)
+			if err != nil {
+				return 0, 0, err
+			}
+			entropy &= mask
+		}
+	}
+	randomness += entropy
+	if randomness > l.MaxRandomness() {
+		return 0, 0, &OverflowError{Field: "randomness", Value: randomness, Max: l.MaxRandomness()}
+	}
+	return 0, randomness, nil
+}
+
+// sleepWithin sleeps until the clock reaches the given timestamp against the epoch unless it takes longer than
+// the remaining wait, and returns the duration slept and whether it slept
+func sleepWithin(clock Clock, epoch time.Time, timestamp int64, remaining time.Duration) (time.Duration, bool) {
+	wait := epoch.Add(time.Duration(timestamp) * time.Millisecond).Sub(clock.Now())
+	if wait > remaining {
+		return 0, false
+	}
+	clock.Sleep(wait)
+	return max(wait, 0), true
+}
+
+// sleepUntil sleeps until the clock reaches the given timestamp against the epoch and returns the duration slept
+func sleepUntil(clock Clock, epoch time.Time, timestamp int64) time.Duration {
+	deadline := epoch.Add(time.Duration(timestamp) * time.Millisecond)
+	wait := deadline.Sub(clock.Now())
+	clock.Sleep(wait)
+	return wait
+}
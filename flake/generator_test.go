@@ -0,0 +1,427 @@
+package flake
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testEpoch is the epoch of the generators under test, one second before their manual clocks start
+var testEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// constantReader fills every read with the same byte
+type constantReader byte
+
+func (r constantReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+func TestNewGenerator(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr error
+	}{
+		{
+			name: "defaults",
+			cfg:  Config{Layout: Standard},
+		},
+		{
+			name:    "invalid layout",
+			cfg:     Config{Layout: Layout{TimestampBits: 43, RandomnessBits: 21}},
+			wantErr: ErrInvalidLayout,
+		},
+		{
+			name:    "node above the layout",
+			cfg:     Config{Layout: Scalable, Node: 32},
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "negative node",
+			cfg:     Config{Layout: Scalable, Node: -1},
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "entropy size above maximum",
+			cfg:     Config{Layout: Standard, EntropySize: MaxEntropySize + 1},
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "unknown overflow policy",
+			cfg:     Config{Layout: Standard, OverflowPolicy: OverflowBorrow + 1},
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "unknown regression policy",
+			cfg:     Config{Layout: Standard, RegressionPolicy: RegressionReuse + 1},
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "negative maximum wait",
+			cfg:     Config{Layout: Standard, MaxWait: -time.Millisecond},
+			wantErr: ErrInvalidConfig,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Epoch = testEpoch
+			g, err := NewGenerator(tt.cfg)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, g)
+				return
+			}
+			require.Nil(t, err)
+			cfg := g.Config()
+			assert.Equal(t, SystemClock{}, cfg.Clock)
+			assert.NotNil(t, cfg.EntropySource)
+			assert.Equal(t, MinEntropySize, cfg.EntropySize)
+			assert.Equal(t, DefaultReserveAhead, cfg.ReserveAhead)
+
+			_, err = g.NewID()
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestGenerator_SetConfig(t *testing.T) {
+	clock := NewManualClock(testEpoch.Add(time.Second))
+	g, err := NewGenerator(Config{Layout: Scalable, Epoch: testEpoch, Node: 1, Clock: clock})
+	require.Nil(t, err)
+	first, err := g.NewID()
+	require.Nil(t, err)
+
+	assert.ErrorIs(t, g.SetConfig(Config{Layout: Scalable, Node: 32}), ErrInvalidConfig)
+	assert.Equal(t, int64(1), g.Config().Node)
+
+	assert.Nil(t, g.SetConfig(Config{Layout: Scalable, Epoch: testEpoch, Node: 2, Clock: clock}))
+	got, err := g.NewID()
+	require.Nil(t, err)
+	assert.Equal(t, int64(2), Scalable.Node(got))
+	assert.Equal(t, Scalable.Timestamp(first), Scalable.Timestamp(got))
+}
+
+func TestGenerator_Layouts(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout Layout
+		node   int64
+	}{
+		{
+			name:   "standard",
+			layout: Standard,
+		},
+		{
+			name:   "scalable",
+			layout: Scalable,
+			node:   31,
+		},
+		{
+			name:   "snowflake",
+			layout: Must(41, 12, 0, 10),
+			node:   1023,
+		},
+		{
+			name:   "sequence and randomness",
+			layout: Must(41, 10, 12, 0),
+		},
+		{
+			name:   "wide randomness",
+			layout: Must(30, 0, 33, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewManualClock(testEpoch.Add(time.Second))
+			g, err := NewGenerator(Config{
+				Layout:         tt.layout,
+				Epoch:          testEpoch,
+				Node:           tt.node,
+				OverflowPolicy: OverflowWait,
+				MaxWait:        DefaultMaxWait,
+				Clock:          clock,
+				EntropySource:  NewSeededSource(1),
+			})
+			require.Nil(t, err)
+
+			previous := int64(-1)
+			for i := 0; i < 10000; i++ {
+				id, err := g.NewID()
+				require.Nil(t, err)
+				assert.Greater(t, id, previous)
+				assert.Equal(t, tt.node, tt.layout.Node(id))
+				assert.Equal(t, clock.Now().Sub(testEpoch).Milliseconds(), tt.layout.Timestamp(id))
+				previous = id
+			}
+		})
+	}
+}
+
+func TestGenerator_Sequence(t *testing.T) {
+	snowflake := Must(41, 12, 0, 10)
+	clock := NewManualClock(testEpoch.Add(time.Second))
+	g, err := NewGenerator(Config{Layout: snowflake, Epoch: testEpoch, Node: 7, Clock: clock})
+	require.Nil(t, err)
+
+	for i := int64(0); i <= snowflake.MaxSequence(); i++ {
+		id, err := g.NewID()
+		require.Nil(t, err)
+		assert.Equal(t, int64(1000), snowflake.Timestamp(id))
+		assert.Equal(t, i, snowflake.Sequence(id))
+		assert.Equal(t, int64(7), snowflake.Node(id))
+	}
+
+	_, err = g.NewID()
+	assert.ErrorIs(t, err, ErrOverflow)
+	var overflowErr *OverflowError
+	require.True(t, errors.As(err, &overflowErr))
+	assert.Equal(t, &OverflowError{Field: "sequence", Value: 4096, Max: 4095}, overflowErr)
+
+	clock.Advance(time.Millisecond)
+	id, err := g.NewID()
+	require.Nil(t, err)
+	assert.Equal(t, int64(1001), snowflake.Timestamp(id))
+	assert.Equal(t, int64(0), snowflake.Sequence(id))
+}
+
+func TestGenerator_OverflowWaitWithoutMaxWait(t *testing.T) {
+	snowflake := Must(41, 12, 0, 10)
+	clock := NewManualClock(testEpoch.Add(time.Second))
+	g, err := NewGenerator(Config{Layout: snowflake, Epoch: testEpoch, OverflowPolicy: OverflowWait, Clock: clock})
+	require.Nil(t, err)
+	require.Nil(t, g.Fill(4096, func(i int, value int64) {}))
+
+	// a zero maximum wait fails without sleeping
+	_, err = g.NewID()
+	assert.ErrorIs(t, err, ErrOverflow)
+	assert.Equal(t, testEpoch.Add(time.Second), clock.Now())
+	assert.Equal(t, Stats{Generated: 4096, Overflows: 1}, g.Stats())
+}
+
+func TestGenerator_OverflowBorrowReuse(t *testing.T) {
+	clock := NewManualClock(testEpoch.Add(time.Second))
+	g, err := NewGenerator(Config{
+		Layout:         Standard,
+		Epoch:          testEpoch,
+		OverflowPolicy: OverflowBorrow,
+		MaxDrift:       2 * time.Millisecond,
+		Clock:          clock,
+	})
+	require.Nil(t, err)
+
+	previous, err := g.NewID()
+	require.Nil(t, err)
+	for _, wantTimestamp := range []int64{1001, 1002} {
+		g.previousRandomness = Standard.MaxRandomness()
+		got, err := g.NewID()
+		require.Nil(t, err)
+		assert.Equal(t, wantTimestamp, Standard.Timestamp(got))
+		assert.Greater(t, got, previous)
+		previous = got
+	}
+
+	// the clock is still behind the borrowed timestamp, so the borrowed millisecond is reused;
+	// its randomness restarts low so that the increments cannot exhaust it
+	g.previousRandomness = 0
+	previous, err = g.NewID()
+	require.Nil(t, err)
+	assert.Equal(t, int64(1002), Standard.Timestamp(previous))
+	got, err := g.NewID()
+	require.Nil(t, err)
+	assert.Equal(t, int64(1002), Standard.Timestamp(got))
+	assert.Greater(t, got, previous)
+	assert.Equal(t, Stats{Generated: 5, Overflows: 2, OverflowBorrows: 2}, g.Stats())
+}
+
+func TestGenerator_OverflowBorrowRegression(t *testing.T) {
+	clock := NewManualClock(testEpoch.Add(time.Second))
+	g, err := NewGenerator(Config{
+		Layout:         Standard,
+		Epoch:          testEpoch,
+		OverflowPolicy: OverflowBorrow,
+		MaxDrift:       DefaultMaxDrift,
+		Clock:          clock,
+	})
+	require.Nil(t, err)
+
+	// a clock moving backwards within the maximum drift is still a regression when nothing was borrowed
+	_, err = g.NewID()
+	require.Nil(t, err)
+	clock.Rewind(time.Millisecond)
+	_, err = g.NewID()
+	assert.Equal(t, &ClockRegressionError{Previous: 1000, Current: 999}, err)
+	assert.Equal(t, Stats{Generated: 1, Regressions: 1}, g.Stats())
+
+	// and so it is behind the clock a millisecond was borrowed at
+	clock.Advance(time.Millisecond)
+	g.previousRandomness = Standard.MaxRandomness()
+	borrowed, err := g.NewID()
+	require.Nil(t, err)
+	assert.Equal(t, int64(1001), Standard.Timestamp(borrowed))
+	clock.Rewind(time.Millisecond)
+	_, err = g.NewID()
+	assert.Equal(t, &ClockRegressionError{Previous: 1001, Current: 999}, err)
+
+	// while the clock the millisecond was borrowed at still reuses it
+	clock.Advance(time.Millisecond)
+	got, err := g.NewID()
+	require.Nil(t, err)
+	assert.Equal(t, int64(1001), Standard.Timestamp(got))
+	assert.Greater(t, got, borrowed)
+	assert.Equal(t, Stats{Generated: 3, Overflows: 1, OverflowBorrows: 1, Regressions: 2}, g.Stats())
+}
+
+func Test_increment(t *testing.T) {
+	tests := []struct {
+		name           string
+		layout         Layout
+		entropySize    int
+		randomness     int64
+		wantRandomness int64
+		wantErr        error
+	}{
+		{
+			name:           "standard",
+			layout:         Standard,
+			entropySize:    1,
+			wantRandomness: 0xFF,
+		},
+		{
+			name:           "standard with a 2-byte entropy",
+			layout:         Standard,
+			entropySize:    2,
+			wantRandomness: 0xFFFF,
+		},
+		{
+			name:        "scalable with a 2-byte entropy",
+			layout:      Scalable,
+			entropySize: 2,
+			wantErr:     ErrOverflow,
+		},
+		{
+			name:           "step masked to the randomness",
+			layout:         Must(43, 0, 10, 10),
+			entropySize:    2,
+			wantRandomness: 7,
+		},
+		{
+			name:           "step of 1 below 9 randomness bits",
+			layout:         Must(43, 0, 8, 12),
+			entropySize:    1,
+			randomness:     5,
+			wantRandomness: 6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got, err := increment(tt.layout, tt.entropySize, 0, tt.randomness, constantReader(0xFF))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantRandomness, got)
+		})
+	}
+}
+
+func Test_generateRandomness(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout Layout
+		random []byte
+		want   int64
+	}{
+		{
+			name:   "minimal value",
+			layout: Standard,
+			random: []byte{0, 0, 0},
+			want:   0,
+		},
+		{
+			name:   "maximal value",
+			layout: Standard,
+			random: []byte{255, 255, 255},
+			want:   1<<20 - 1,
+		},
+		{
+			name:   "scalable",
+			layout: Scalable,
+			random: []byte{255, 255},
+			want:   1<<15 - 1,
+		},
+		{
+			name:   "wide randomness",
+			layout: Must(30, 0, 33, 0),
+			random: []byte{255, 255, 255, 255, 255},
+			want:   1<<33 - 1,
+		},
+		{
+			name:   "no randomness",
+			layout: Must(41, 12, 0, 10),
+			want:   0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := bytes.NewReader(tt.random)
+			got, err := generateRandomness(tt.layout, source)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Zero(t, source.Len())
+		})
+	}
+}
+
+func Test_generateEntropy(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		random  []byte
+		want    int64
+		wantErr bool
+	}{
+		{
+			name:   "minimal value",
+			size:   1,
+			random: []byte{0},
+			want:   0,
+		},
+		{
+			name:   "maximal value",
+			size:   3,
+			random: []byte{255, 255, 255},
+			want:   1<<24 - 1,
+		},
+		{
+			name:    "too big size",
+			size:    4,
+			random:  []byte{255, 255, 255, 255},
+			wantErr: true,
+		},
+		{
+			name:    "too small size",
+			size:    0,
+			random:  []byte{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := generateEntropy(tt.size, bytes.NewReader(tt.random))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidConfig)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
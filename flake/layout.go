@@ -0,0 +1,147 @@
+// Package flake describes the bit layouts of Ulid-Flakes and provides the generators of any layout,
+// which the stand-alone and the scalable packages wrap, together with what they share: the Base32 encoding,
+// the error types, the clocks, the entropy sources, the overflow and regression policies and the
+// high-water mark stores.
+//
+// A Layout splits the 63 bits of a non-negative int64 into a timestamp, an optional sequence,
+// a randomness and an optional node (scalability ID) field, from the most to the least significant bits.
+// The stand-alone and the scalable packages issue the Standard and Scalable layouts, the latter with
+// a node of 5 to 15 bits. Other layouts, such as the Snowflake-like 41/12/0/10, are generated by the
+// Generator and ConcurrentGenerator of this package directly.
+package flake
+
+import "fmt"
+
+const Bits = 63 // Number of bits of a layout, the 64-bit signed integer size without sign bit
+
+// Layout describes the number of bits of each field of a Ulid-Flake
+type Layout struct {
+	TimestampBits  int // Milliseconds since the epoch
+	SequenceBits   int // Per-millisecond counter, optional
+	RandomnessBits int // Randomness, incremented within a millisecond
+	NodeBits       int // Scalability ID of the node, optional
+}
+
+var (
+	Standard = Layout{TimestampBits: 43, RandomnessBits: 20}              // Layout of the stand-alone Ulid-Flake
+	Scalable = Layout{TimestampBits: 43, RandomnessBits: 15, NodeBits: 5} // Layout of the scalable Ulid-Flake
+)
+
+// New creates a validated Layout from the number of bits of each field
+func New(timestampBits, sequenceBits, randomnessBits, nodeBits int) (Layout, error) {
+	l := Layout{
+		TimestampBits:  timestampBits,
+		SequenceBits:   sequenceBits,
+		RandomnessBits: randomnessBits,
+		NodeBits:       nodeBits,
+	}
+	if err := l.Validate(); err != nil {
+		return Layout{}, err
+	}
+	return l, nil
+}
+
+// Must is like New but panics if the layout is invalid
+func Must(timestampBits, sequenceBits, randomnessBits, nodeBits int) Layout {
+	l, err := New(timestampBits, sequenceBits, randomnessBits, nodeBits)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// Validate checks that the fields add up to 63 bits, so that the sign bit stays clear and the
+// Base32 strings sort in the same order as the integers, and that Ulid-Flakes generated within
+// the same millisecond can be distinguished by a sequence or a randomness
+func (l Layout) Validate() error {
+	if l.TimestampBits < 1 || l.SequenceBits < 0 || l.RandomnessBits < 0 || l.NodeBits < 0 {
+		return fmt.Errorf("%w: %v: negative or missing field", ErrInvalidLayout, l)
+	}
+	if l.SequenceBits+l.RandomnessBits < 1 {
+		return fmt.Errorf("%w: %v: no sequence or randomness bits", ErrInvalidLayout, l)
+	}
+	if total := l.TimestampBits + l.SequenceBits + l.RandomnessBits + l.NodeBits; total != Bits {
+		return fmt.Errorf("%w: %v: %d bits, want %d", ErrInvalidLayout, l, total, Bits)
+	}
+	return nil
+}
+
+// String returns the number of bits of each field as timestamp/sequence/randomness/node
+func (l Layout) String() string {
+	return fmt.Sprintf("%d/%d/%d/%d", l.TimestampBits, l.SequenceBits, l.RandomnessBits, l.NodeBits)
+}
+
+// MaxTimestamp returns the maximum value of the timestamp field
+func (l Layout) MaxTimestamp() int64 {
+	return maxValue(l.TimestampBits)
+}
+
+// MaxSequence returns the maximum value of the sequence field
+func (l Layout) MaxSequence() int64 {
+	return maxValue(l.SequenceBits)
+}
+
+// MaxRandomness returns the maximum value of the randomness field
+func (l Layout) MaxRandomness() int64 {
+	return maxValue(l.RandomnessBits)
+}
+
+// MaxNode returns the maximum value of the node field
+func (l Layout) MaxNode() int64 {
+	return maxValue(l.NodeBits)
+}
+
+// Pack combines the fields into a Ulid-Flake value. Each field is masked to its number of bits.
+func (l Layout) Pack(timestamp, sequence, randomness, node int64) int64 {
+	return (timestamp&l.MaxTimestamp())<<l.timestampShift() |
+		(sequence&l.MaxSequence())<<l.sequenceShift() |
+		(randomness&l.MaxRandomness())<<l.randomnessShift() |
+		node&l.MaxNode()
+}
+
+// Timestamp returns the timestamp field of the value
+func (l Layout) Timestamp(value int64) int64 {
+	return (value >> l.timestampShift()) & l.MaxTimestamp()
+}
+
+// Sequence returns the sequence field of the value
+func (l Layout) Sequence(value int64) int64 {
+	return (value >> l.sequenceShift()) & l.MaxSequence()
+}
+
+// Randomness returns the randomness field of the value
+func (l Layout) Randomness(value int64) int64 {
+	return (value >> l.randomnessShift()) & l.MaxRandomness()
+}
+
+// Node returns the node field of the value
+func (l Layout) Node(value int64) int64 {
+	return value & l.MaxNode()
+}
+
+// MinForTimestamp returns the smallest value with the given timestamp
+func (l Layout) MinForTimestamp(timestamp int64) int64 {
+	return l.Pack(timestamp, 0, 0, 0)
+}
+
+// MaxForTimestamp returns the largest value with the given timestamp
+func (l Layout) MaxForTimestamp(timestamp int64) int64 {
+	return l.Pack(timestamp, l.MaxSequence(), l.MaxRandomness(), l.MaxNode())
+}
+
+func (l Layout) timestampShift() int {
+	return l.SequenceBits + l.RandomnessBits + l.NodeBits
+}
+
+func (l Layout) sequenceShift() int {
+	return l.RandomnessBits + l.NodeBits
+}
+
+func (l Layout) randomnessShift() int {
+	return l.NodeBits
+}
+
+// maxValue returns the maximum value of a field of the given number of bits
+func maxValue(bits int) int64 {
+	return 1<<bits - 1
+}
@@ -0,0 +1,153 @@
+package flake
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name           string
+		timestampBits  int
+		sequenceBits   int
+		randomnessBits int
+		nodeBits       int
+		wantErr        bool
+	}{
+		{
+			name:           "standard",
+			timestampBits:  43,
+			randomnessBits: 20,
+		},
+		{
+			name:           "scalable",
+			timestampBits:  43,
+			randomnessBits: 15,
+			nodeBits:       5,
+		},
+		{
+			name:          "41/12/10 with a sequence",
+			timestampBits: 41,
+			sequenceBits:  12,
+			nodeBits:      10,
+		},
+		{
+			name:           "43/10/10",
+			timestampBits:  43,
+			randomnessBits: 10,
+			nodeBits:       10,
+		},
+		{
+			name:           "sequence and randomness",
+			timestampBits:  43,
+			sequenceBits:   4,
+			randomnessBits: 12,
+			nodeBits:       4,
+		},
+		{
+			name:           "more than 63 bits",
+			timestampBits:  44,
+			randomnessBits: 20,
+			wantErr:        true,
+		},
+		{
+			name:           "less than 63 bits",
+			timestampBits:  42,
+			randomnessBits: 20,
+			wantErr:        true,
+		},
+		{
+			name:          "no sequence or randomness",
+			timestampBits: 43,
+			nodeBits:      20,
+			wantErr:       true,
+		},
+		{
+			name:           "no timestamp",
+			randomnessBits: 63,
+			wantErr:        true,
+		},
+		{
+			name:           "negative field",
+			timestampBits:  43,
+			randomnessBits: 25,
+			nodeBits:       -5,
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.timestampBits, tt.sequenceBits, tt.randomnessBits, tt.nodeBits)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidLayout)
+				assert.Equal(t, Layout{}, got)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, Layout{tt.timestampBits, tt.sequenceBits, tt.randomnessBits, tt.nodeBits}, got)
+		})
+	}
+
+	assert.Panics(t, func() { Must(44, 0, 20, 0) })
+}
+
+func TestLayout_Predefined(t *testing.T) {
+	assert.Nil(t, Standard.Validate())
+	assert.Equal(t, "43/0/20/0", Standard.String())
+	assert.Equal(t, int64(8796093022207), Standard.MaxTimestamp())
+	assert.Equal(t, int64(1048575), Standard.MaxRandomness())
+	assert.Equal(t, int64(0), Standard.MaxNode())
+
+	assert.Nil(t, Scalable.Validate())
+	assert.Equal(t, "43/0/15/5", Scalable.String())
+	assert.Equal(t, int64(32767), Scalable.MaxRandomness())
+	assert.Equal(t, int64(31), Scalable.MaxNode())
+}
+
+func TestLayout_Pack(t *testing.T) {
+	layouts := []Layout{Standard, Scalable, Must(41, 12, 0, 10), Must(43, 0, 10, 10), Must(40, 5, 10, 8)}
+	for _, l := range layouts {
+		t.Run(l.String(), func(t *testing.T) {
+			timestamp, sequence := l.MaxTimestamp()/3, l.MaxSequence()/3
+			randomness, node := l.MaxRandomness()/3, l.MaxNode()/3
+
+			value := l.Pack(timestamp, sequence, randomness, node)
+			assert.GreaterOrEqual(t, value, int64(0))
+			assert.Equal(t, timestamp, l.Timestamp(value))
+			assert.Equal(t, sequence, l.Sequence(value))
+			assert.Equal(t, randomness, l.Randomness(value))
+			assert.Equal(t, node, l.Node(value))
+
+			assert.Equal(t, int64(0), l.MinForTimestamp(0))
+			assert.Equal(t, int64(1<<Bits-1), l.MaxForTimestamp(l.MaxTimestamp()))
+			assert.Equal(t, timestamp, l.Timestamp(l.MaxForTimestamp(timestamp)))
+			assert.Equal(t, timestamp, l.Timestamp(l.MinForTimestamp(timestamp)))
+		})
+	}
+}
+
+func TestLayout_SortOrder(t *testing.T) {
+	layouts := []Layout{Standard, Scalable, Must(41, 12, 0, 10), Must(43, 0, 10, 10)}
+	for _, l := range layouts {
+		t.Run(l.String(), func(t *testing.T) {
+			r := rand.New(rand.NewPCG(1, 2))
+			values := make([]int64, 1000)
+			for i := range values {
+				values[i] = l.Pack(r.Int64N(l.MaxTimestamp()+1), r.Int64(), r.Int64(), r.Int64())
+			}
+			slices.Sort(values)
+
+			encoded := make([]string, len(values))
+			for i, value := range values {
+				encoded[i] = string(AppendBase32(nil, value, Len))
+			}
+			assert.True(t, slices.IsSorted(encoded))
+			for i := 1; i < len(values); i++ {
+				assert.LessOrEqual(t, l.Timestamp(values[i-1]), l.Timestamp(values[i]))
+			}
+		})
+	}
+}
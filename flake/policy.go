@@ -0,0 +1,37 @@
+package flake
+
+import "time"
+
+// OverflowPolicy defines how a generator behaves when the randomness is exhausted within a millisecond
+type OverflowPolicy int
+
+const (
+	OverflowFail   OverflowPolicy = iota // Return ErrOverflow (default)
+	OverflowWait                         // Block until the next millisecond
+	OverflowBorrow                       // Borrow from the next millisecond, bounded by the maximum drift
+)
+
+// RegressionPolicy defines how a generator behaves when the clock moves backwards
+type RegressionPolicy int
+
+const (
+	RegressionFail  RegressionPolicy = iota // Return ErrInvalidTimestamp (default)
+	RegressionWait                          // Wait until the clock catches up, bounded by the maximum wait
+	RegressionReuse                         // Keep issuing from the last-seen timestamp while incrementing randomness
+)
+
+const (
+	DefaultMaxDrift = 10 * time.Millisecond  // Default maximum drift of a borrowed timestamp ahead of the clock
+	DefaultMaxWait  = 100 * time.Millisecond // Default maximum wait for the clock to catch up or to reach the next millisecond
+)
+
+// Stats holds counters of the paths taken by a generator
+type Stats struct {
+	Generated        uint64 // Ulid-Flakes generated
+	Overflows        uint64 // Randomness exhausted within a millisecond
+	OverflowWaits    uint64 // Overflows resolved by waiting for the next millisecond
+	OverflowBorrows  uint64 // Overflows resolved by borrowing from the next millisecond
+	Regressions      uint64 // Clock moved backwards behind the last-seen timestamp
+	RegressionWaits  uint64 // Regressions resolved by waiting for the clock to catch up
+	RegressionReuses uint64 // Regressions resolved by reusing the last-seen timestamp
+}
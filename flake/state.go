@@ -0,0 +1,143 @@
+package flake
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultReserveAhead = time.Second // Default time the persisted high-water mark is reserved ahead of the generated timestamps
+
+// StateStore persists the high-water mark of a generator: a time all the Ulid-Flakes it issued were generated before.
+// A generator with a StateStore treats the loaded mark as its previous timestamp, so after a restart it does not issue
+// Ulid-Flakes sorting before or duplicating the ones issued before, even if the clock was set back meanwhile.
+type StateStore interface {
+	// Load returns the persisted high-water mark, or the zero time if none was persisted yet
+	Load() (time.Time, error)
+
+	// Save durably persists the high-water mark
+	Save(mark time.Time) error
+}
+
+// FileStateStore is a StateStore persisting the high-water mark as Unix milliseconds in a file.
+// It replaces the file atomically and syncs it to disk on every save.
+type FileStateStore struct {
+	path string
+}
+
+// NewFileStateStore creates a FileStateStore on the file at the path
+func NewFileStateStore(path string) *FileStateStore {
+	return &FileStateStore{path: path}
+}
+
+// Load reads the high-water mark from the file
+func (s *FileStateStore) Load() (time.Time, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	unixMilli, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: corrupt state file %s", ErrInvalidConfig, s.path)
+	}
+	return time.UnixMilli(unixMilli).UTC(), nil
+}
+
+// Save writes the high-water mark to a temporary file, syncs it and renames it over the file
+func (s *FileStateStore) Save(mark time.Time) error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(strconv.FormatInt(mark.UnixMilli(), 10) + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	// Sync the directory for the rename to be durable, where the platform supports it
+	if dir, err := os.Open(filepath.Dir(s.path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// markTimestamp returns the timestamp of the high-water mark against the epoch, or 0 for the zero time
+func markTimestamp(mark time.Time, epoch time.Time) int64 {
+	if mark.IsZero() {
+		return 0
+	}
+	return max(mark.Sub(epoch).Milliseconds(), 0)
+}
+
+// restoreState resumes from the loaded high-water mark with the minimal sequence and randomness. The Ulid-Flakes issued
+// before the mark was saved are all before it, so the first one generated at the mark is still greater than them.
+func (g *Generator) restoreState(mark time.Time) {
+	g.highWater = markTimestamp(mark, g.config.Epoch)
+	if g.highWater > g.previousTimestamp {
+		g.previousTimestamp = g.highWater
+		g.previousSequence = 0
+		g.previousRandomness = 0
+	}
+}
+
+// reserve persists a new high-water mark ahead of the timestamp, unless the current one is still ahead of it
+func (g *Generator) reserve(timestamp int64) error {
+	if g.config.StateStore == nil || timestamp < g.highWater {
+		return nil
+	}
+	next := timestamp + g.config.ReserveAhead.Milliseconds()
+	if err := g.config.StateStore.Save(g.config.Epoch.Add(time.Duration(next) * time.Millisecond)); err != nil {
+		return err
+	}
+	g.highWater = next
+	return nil
+}
+
+// restoreState resumes from the loaded high-water mark as Generator.restoreState does
+func (g *ConcurrentGenerator) restoreState(mark time.Time) {
+	highWater := markTimestamp(mark, g.config.Epoch)
+	g.highWater.Store(highWater)
+	if highWater > 0 {
+		g.state.Store(g.pack(highWater, 0, 0))
+	}
+}
+
+// reserve persists a new high-water mark ahead of the timestamp, unless the current one is still ahead of it.
+// Only one goroutine saves at a time, and the others wait for it.
+func (g *ConcurrentGenerator) reserve(timestamp int64) error {
+	if g.config.StateStore == nil || timestamp < g.highWater.Load() {
+		return nil
+	}
+	g.reserveMutex.Lock()
+	defer g.reserveMutex.Unlock()
+
+	if timestamp < g.highWater.Load() {
+		return nil
+	}
+	next := timestamp + g.config.ReserveAhead.Milliseconds()
+	if err := g.config.StateStore.Save(g.config.Epoch.Add(time.Duration(next) * time.Millisecond)); err != nil {
+		return err
+	}
+	g.highWater.Store(next)
+	return nil
+}
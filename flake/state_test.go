@@ -0,0 +1,63 @@
+package flake
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStateStore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ulidflake.state")
+	store := NewFileStateStore(path)
+
+	mark, err := store.Load()
+	assert.Nil(t, err)
+	assert.True(t, mark.IsZero())
+
+	want := time.Date(2024, 7, 6, 10, 47, 39, 598000000, time.UTC)
+	assert.Nil(t, store.Save(want))
+	assert.Nil(t, store.Save(want.Add(time.Second)))
+	mark, err = store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, want.Add(time.Second), mark)
+
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.Nil(t, os.WriteFile(path, []byte("garbage"), 0o644))
+	_, err = store.Load()
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+
+	assert.NotNil(t, NewFileStateStore(filepath.Join(dir, "missing", "state")).Save(want))
+}
+
+func TestGenerator_StateStoreRestartStats(t *testing.T) {
+	clock := NewManualClock(testEpoch.Add(time.Second))
+	cfg := Config{
+		Layout:        Standard,
+		Epoch:         testEpoch,
+		Clock:         clock,
+		EntropySource: constantReader(1),
+		StateStore:    NewFileStateStore(filepath.Join(t.TempDir(), "state")),
+		ReserveAhead:  50 * time.Millisecond,
+	}
+	g, err := NewGenerator(cfg)
+	require.Nil(t, err)
+	_, err = g.NewID()
+	require.Nil(t, err)
+
+	// a restart within the reserved window waits for the mark instead of counting a regression
+	clock.Advance(10 * time.Millisecond)
+	g, err = NewGenerator(cfg)
+	require.Nil(t, err)
+	_, err = g.NewID()
+	require.Nil(t, err)
+	assert.Equal(t, testEpoch.Add(time.Second+50*time.Millisecond), clock.Now())
+	assert.Equal(t, Stats{Generated: 1}, g.Stats())
+}
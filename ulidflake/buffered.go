@@ -0,0 +1,97 @@
+package ulidflake
+
+import (
+	"context"
+	"time"
+
+	"github.com/abailinrun/ulid-flake-go/flake"
+)
+
+const (
+	DefaultBufferedSize = flake.DefaultBufferedSize // Default number of Ulid-Flakes kept ahead by a BufferedGenerator
+	DefaultStaleAfter   = flake.DefaultStaleAfter   // Default age after which a buffered Ulid-Flake is discarded
+)
+
+// BufferedGenerator serves Ulid-Flakes pre-generated by a background goroutine,
+// so that they are available without reading the clock and entropy source on the critical path.
+// Buffered Ulid-Flakes older than the stale threshold are discarded, so that they still approximate
+// the time they are handed out at. The staleness is judged against the generator's clock and epoch
+// at creation. A BufferedGenerator is safe for concurrent use by multiple goroutines.
+type BufferedGenerator struct {
+	buffered *flake.BufferedGenerator
+}
+
+// BufferedOption defines the type for functional options of a BufferedGenerator
+type BufferedOption = flake.BufferedOption
+
+// NewBufferedGenerator creates a new BufferedGenerator refilled from the generator until it is closed
+// or the context is done. Overflows are waited out in the background, while other generation errors are
+// returned to the callers waiting on an empty buffer as they occur, and are never buffered.
+func NewBufferedGenerator(ctx context.Context, g *Generator, opts ...BufferedOption) (*BufferedGenerator, error) {
+	if g == nil {
+		return nil, ErrInvalidConfig
+	}
+	buffered, err := flake.NewBufferedGenerator(ctx, g.generator, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &BufferedGenerator{buffered: buffered}, nil
+}
+
+// New returns a new Ulid-Flake from the buffer
+func (b *BufferedGenerator) New() (*UlidFlake, error) {
+	id, err := b.NewID()
+	if err != nil {
+		return nil, err
+	}
+	return NewUlidFlake(int64(id))
+}
+
+// NewID returns a new Ulid-Flake as an ID from the buffer, waiting for the refill if it is empty.
+// While waiting, it returns the error of a failed refill. It returns ErrClosed once the generator
+// is closed or its context is done.
+func (b *BufferedGenerator) NewID() (ID, error) {
+	id, err := b.buffered.NewID()
+	return ID(id), err
+}
+
+// NewContext returns a new Ulid-Flake from the buffer, waiting for the refill until the context is done
+func (b *BufferedGenerator) NewContext(ctx context.Context) (*UlidFlake, error) {
+	id, err := b.NewIDContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewUlidFlake(int64(id))
+}
+
+// NewIDContext returns a new Ulid-Flake as an ID from the buffer as NewID does, but waits for the refill
+// only until the context is done, and then returns the context's error.
+func (b *BufferedGenerator) NewIDContext(ctx context.Context) (ID, error) {
+	id, err := b.buffered.NewIDContext(ctx)
+	return ID(id), err
+}
+
+// Len returns the number of Ulid-Flakes currently buffered
+func (b *BufferedGenerator) Len() int {
+	return b.buffered.Len()
+}
+
+// Discarded returns the number of buffered Ulid-Flakes discarded as stale
+func (b *BufferedGenerator) Discarded() uint64 {
+	return b.buffered.Discarded()
+}
+
+// Close stops the refill and waits for the background goroutine to exit
+func (b *BufferedGenerator) Close() error {
+	return b.buffered.Close()
+}
+
+// WithBufferSize sets the number of Ulid-Flakes kept ahead
+func WithBufferSize(size int) BufferedOption {
+	return flake.WithBufferSize(size)
+}
+
+// WithStaleAfter sets the age after which a buffered Ulid-Flake is discarded
+func WithStaleAfter(d time.Duration) BufferedOption {
+	return flake.WithStaleAfter(d)
+}
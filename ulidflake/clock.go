@@ -0,0 +1,31 @@
+package ulidflake
+
+import (
+	"time"
+
+	"github.com/abailinrun/ulid-flake-go/flake"
+)
+
+// Clock provides the current time to a Generator and lets it wait for the time to pass
+type Clock = flake.Clock
+
+// SystemClock reads the wall clock of the system
+type SystemClock = flake.SystemClock
+
+// MonotonicClock reads the wall clock once and then advances with the monotonic clock,
+// so that it never moves backwards when the wall clock is stepped
+type MonotonicClock = flake.MonotonicClock
+
+// ManualClock is a clock that only moves when told to, for deterministic testing.
+// Sleep advances the clock by the given duration unless the clock is frozen.
+type ManualClock = flake.ManualClock
+
+// NewMonotonicClock creates a new MonotonicClock anchored at the current wall clock time
+func NewMonotonicClock() *MonotonicClock {
+	return flake.NewMonotonicClock()
+}
+
+// NewManualClock creates a new ManualClock set to the given time
+func NewManualClock(t time.Time) *ManualClock {
+	return flake.NewManualClock(t)
+}
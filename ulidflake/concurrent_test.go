@@ -0,0 +1,222 @@
+package ulidflake
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConcurrentGenerator(t *testing.T) {
+	g, err := NewConcurrentGenerator(WithEntropySize(MaxEntropySize), WithOverflowPolicy(OverflowBorrow))
+	assert.Nil(t, err)
+	assert.Equal(t, MaxEntropySize, g.generator.Config().EntropySize)
+	assert.Equal(t, OverflowBorrow, g.generator.Config().OverflowPolicy)
+
+	_, err = NewConcurrentGenerator(WithEntropySize(0))
+	assert.ErrorIs(t, err, ErrInvalidEntropy)
+}
+
+func TestConcurrentGenerator_New(t *testing.T) {
+	g, err := NewConcurrentGenerator(WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+
+	previous, err := g.New()
+	assert.Nil(t, err)
+	for i := 0; i < 1000; i++ {
+		got, err := g.New()
+		assert.Nil(t, err)
+		assert.Greater(t, got.Int(), previous.Int())
+		previous = got
+	}
+	assert.Equal(t, uint64(1001), g.Stats().Generated)
+}
+
+func TestConcurrentGenerator_Concurrent(t *testing.T) {
+	const goroutines, perGoroutine = 8, 1000
+	g, err := NewConcurrentGenerator(WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+
+	results := make([][]ID, goroutines)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perGoroutine; j++ {
+				id, err := g.NewID()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				results[i] = append(results[i], id)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[ID]bool, goroutines*perGoroutine)
+	for _, ids := range results {
+		for j, id := range ids {
+			if j > 0 {
+				assert.Less(t, ids[j-1], id)
+			}
+			assert.False(t, seen[id])
+			seen[id] = true
+		}
+	}
+	assert.Len(t, seen, goroutines*perGoroutine)
+}
+
+func TestConcurrentGenerator_OverflowPolicy(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC)
+	tests := []struct {
+		name          string
+		policy        OverflowPolicy
+		wantTimestamp int64
+		wantClock     time.Time
+		wantStats     Stats
+		wantErr       error
+	}{
+		{
+			name:      "error",
+			policy:    OverflowFail,
+			wantStats: Stats{Generated: 1, Overflows: 1},
+			wantErr:   ErrOverflow,
+		},
+		{
+			name:          "wait until the next millisecond",
+			policy:        OverflowWait,
+			wantTimestamp: 1001,
+			wantClock:     start.Add(time.Millisecond),
+			wantStats:     Stats{Generated: 2, Overflows: 1, OverflowWaits: 1},
+		},
+		{
+			name:          "borrow from the next millisecond",
+			policy:        OverflowBorrow,
+			wantTimestamp: 1001,
+			wantClock:     start,
+			wantStats:     Stats{Generated: 2, Overflows: 1, OverflowBorrows: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewManualClock(start)
+			// a saturated entropy gives the first Ulid-Flake the maximum randomness, so that the next one overflows
+			g, err := NewConcurrentGenerator(WithClock(clock), WithOverflowPolicy(tt.policy), WithEntropySource(constantReader(0xFF)))
+			assert.Nil(t, err)
+
+			first, err := g.New()
+			assert.Nil(t, err)
+			assert.Equal(t, int64(1000), first.Timestamp())
+			assert.Equal(t, int64(MaxRandomness), first.Randomness())
+
+			got, err := g.New()
+			assert.Equal(t, tt.wantStats, g.Stats())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantTimestamp, got.Timestamp())
+			assert.Greater(t, got.Int(), first.Int())
+			assert.Equal(t, tt.wantClock, clock.Now())
+		})
+	}
+}
+
+func TestConcurrentGenerator_RegressionPolicy(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		policy     RegressionPolicy
+		regression time.Duration
+		wantClock  time.Time
+		wantStats  Stats
+		wantErr    error
+	}{
+		{
+			name:       "error",
+			policy:     RegressionFail,
+			regression: 5 * time.Millisecond,
+			wantClock:  start.Add(-5 * time.Millisecond),
+			wantStats:  Stats{Generated: 1, Regressions: 1},
+			wantErr:    ErrInvalidTimestamp,
+		},
+		{
+			name:       "wait until the clock catches up",
+			policy:     RegressionWait,
+			regression: 5 * time.Millisecond,
+			wantClock:  start,
+			wantStats:  Stats{Generated: 2, Regressions: 1, RegressionWaits: 1},
+		},
+		{
+			name:       "wait longer than the maximum wait",
+			policy:     RegressionWait,
+			regression: DefaultMaxWait + time.Millisecond,
+			wantClock:  start.Add(-DefaultMaxWait - time.Millisecond),
+			wantStats:  Stats{Generated: 1, Regressions: 1},
+			wantErr:    ErrInvalidTimestamp,
+		},
+		{
+			name:       "reuse the last-seen timestamp",
+			policy:     RegressionReuse,
+			regression: time.Hour,
+			wantClock:  start.Add(-time.Hour),
+			wantStats:  Stats{Generated: 2, Regressions: 1, RegressionReuses: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewManualClock(start)
+			g, err := NewConcurrentGenerator(WithClock(clock), WithRegressionPolicy(tt.policy), WithEntropySource(constantReader(1)))
+			require.Nil(t, err)
+
+			first, err := g.New()
+			require.Nil(t, err)
+
+			clock.Rewind(tt.regression)
+			got, err := g.New()
+			assert.Equal(t, tt.wantClock, clock.Now())
+			assert.Equal(t, tt.wantStats, g.Stats())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, first.Timestamp(), got.Timestamp())
+			assert.Greater(t, got.Int(), first.Int())
+		})
+	}
+}
+
+func BenchmarkParallel(b *testing.B) {
+	b.Run("Generator", func(b *testing.B) {
+		g, err := NewGenerator(WithOverflowPolicy(OverflowWait), WithEntropySource(runtimeReader{}))
+		assert.Nil(b, err)
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := g.NewID(); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+	})
+	b.Run("ConcurrentGenerator", func(b *testing.B) {
+		g, err := NewConcurrentGenerator(WithOverflowPolicy(OverflowWait), WithEntropySource(runtimeReader{}))
+		assert.Nil(b, err)
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := g.NewID(); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+	})
+}
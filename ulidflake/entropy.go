@@ -0,0 +1,34 @@
+package ulidflake
+
+import (
+	"io"
+
+	"github.com/abailinrun/ulid-flake-go/flake"
+)
+
+const DefaultBufferSize = flake.DefaultBufferSize // Default size of the buffer of a buffered entropy source in bytes
+
+// EntropySource provides the random bytes for the randomness and entropy of a Generator
+type EntropySource = flake.EntropySource
+
+// NewCryptoSource creates an unpredictable EntropySource reading from crypto/rand
+func NewCryptoSource() EntropySource {
+	return flake.NewCryptoSource()
+}
+
+// NewBufferedSource creates an EntropySource that reads from r in chunks of the given size,
+// amortizing the cost of the underlying reads across many Ulid-Flakes
+func NewBufferedSource(r io.Reader, size int) EntropySource {
+	return flake.NewBufferedSource(r, size)
+}
+
+// NewSeededSource creates a deterministic EntropySource from the given seed, for tests and golden files.
+// It is predictable and must not be used where unpredictability matters.
+func NewSeededSource(seed uint64) EntropySource {
+	return flake.NewSeededSource(seed)
+}
+
+// NewChaCha8Source creates a fast buffered EntropySource backed by the ChaCha8 generator
+func NewChaCha8Source(seed [32]byte) EntropySource {
+	return flake.NewChaCha8Source(seed)
+}
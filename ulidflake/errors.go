@@ -0,0 +1,15 @@
+package ulidflake
+
+import "github.com/abailinrun/ulid-flake-go/flake"
+
+// ParseError describes why a Ulid-Flake string could not be parsed.
+// It matches ErrInvalidULID, or ErrOverflow if the string encodes a value beyond 63 bits, with errors.Is.
+type ParseError = flake.ParseError
+
+// ClockRegressionError describes a clock that moved backwards behind the last-seen timestamp.
+// It matches ErrInvalidTimestamp with errors.Is.
+type ClockRegressionError = flake.ClockRegressionError
+
+// OverflowError describes a component that does not fit into its bits.
+// It matches ErrOverflow with errors.Is.
+type OverflowError = flake.OverflowError
@@ -0,0 +1,112 @@
+package ulidflake
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		strict     bool
+		wantOffset int
+		wantReason string
+		wantErr    error
+		wantString string
+	}{
+		{
+			name:       "invalid character",
+			input:      "00CMXB6TAK4SU",
+			wantOffset: 12,
+			wantReason: "invalid character 'U'",
+			wantErr:    ErrInvalidULID,
+			wantString: `invalid ULID: parsing "00CMXB6TAK4SU": invalid character 'U' at offset 12`,
+		},
+		{
+			name:       "non-canonical character in strict mode",
+			input:      "00cMXB6TAK4SA",
+			strict:     true,
+			wantOffset: 2,
+			wantReason: "non-canonical character 'c' in strict mode",
+			wantErr:    ErrInvalidULID,
+			wantString: `invalid ULID: parsing "00cMXB6TAK4SA": non-canonical character 'c' in strict mode at offset 2`,
+		},
+		{
+			name:       "leading character above 7",
+			input:      "8000000000000",
+			wantOffset: 0,
+			wantReason: "leading character '8' above '7'",
+			wantErr:    ErrOverflow,
+			wantString: `overflow error: parsing "8000000000000": leading character '8' above '7' at offset 0`,
+		},
+		{
+			name:       "invalid length",
+			input:      "00CMXB6TAK4S",
+			wantOffset: -1,
+			wantReason: "length 12, want 13",
+			wantErr:    ErrInvalidULID,
+			wantString: `invalid ULID: parsing "00CMXB6TAK4S": length 12, want 13`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseID(tt.input, tt.strict)
+			assert.ErrorIs(t, err, tt.wantErr)
+			var parseErr *ParseError
+			assert.True(t, errors.As(err, &parseErr))
+			assert.Equal(t, tt.input, parseErr.Input)
+			assert.Equal(t, tt.wantOffset, parseErr.Offset)
+			assert.Equal(t, tt.wantReason, parseErr.Reason)
+			assert.Equal(t, tt.wantString, err.Error())
+		})
+	}
+}
+
+func TestClockRegressionError(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	g, err := NewGenerator(WithClock(clock))
+	assert.Nil(t, err)
+
+	_, err = g.New()
+	assert.Nil(t, err)
+	clock.Rewind(5 * time.Millisecond)
+	_, err = g.New()
+
+	assert.ErrorIs(t, err, ErrInvalidTimestamp)
+	var regressionErr *ClockRegressionError
+	assert.True(t, errors.As(err, &regressionErr))
+	assert.Equal(t, int64(86400000), regressionErr.Previous)
+	assert.Equal(t, int64(86399995), regressionErr.Current)
+	assert.Equal(t, 5*time.Millisecond, regressionErr.Regression())
+	assert.Equal(t, "invalid timestamp: clock moved backwards by 5ms (previous timestamp 86400000, current timestamp 86399995)", err.Error())
+}
+
+func TestOverflowError(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC))
+	// a saturated entropy gives the first Ulid-Flake the maximum randomness, so that the next one overflows
+	g, err := NewGenerator(WithClock(clock), WithEntropySource(constantReader(0xFF)))
+	assert.Nil(t, err)
+
+	_, err = g.New()
+	assert.Nil(t, err)
+	_, err = g.New()
+
+	assert.ErrorIs(t, err, ErrOverflow)
+	var overflowErr *OverflowError
+	assert.True(t, errors.As(err, &overflowErr))
+	assert.Equal(t, "randomness", overflowErr.Field)
+	assert.Greater(t, overflowErr.Value, int64(MaxRandomness))
+	assert.Equal(t, int64(MaxRandomness), overflowErr.Max)
+
+	_, err = generateTimestamp(time.Unix(DefaultEpochSec, 0).Add(-time.Millisecond), time.Unix(DefaultEpochSec, 0))
+	assert.Equal(t, &OverflowError{Field: "timestamp", Value: -1, Max: MaxTimestamp}, err)
+	assert.Equal(t, "overflow error: timestamp -1 out of range [0, 8796093022207]", err.Error())
+
+	_, err = FromInt(-1)
+	assert.Equal(t, &OverflowError{Field: "value", Value: -1, Max: MaxInt}, err)
+}
@@ -0,0 +1,227 @@
+package ulidflake
+
+import (
+	"time"
+
+	"github.com/abailinrun/ulid-flake-go/flake"
+)
+
+// OverflowPolicy defines how a Generator behaves when the randomness is exhausted within a millisecond
+type OverflowPolicy = flake.OverflowPolicy
+
+const (
+	OverflowFail   = flake.OverflowFail   // Return ErrOverflow (default)
+	OverflowWait   = flake.OverflowWait   // Block until the next millisecond
+	OverflowBorrow = flake.OverflowBorrow // Borrow from the next millisecond, bounded by the maximum drift
+)
+
+// RegressionPolicy defines how a Generator behaves when the clock moves backwards
+type RegressionPolicy = flake.RegressionPolicy
+
+const (
+	RegressionFail  = flake.RegressionFail  // Return ErrInvalidTimestamp (default)
+	RegressionWait  = flake.RegressionWait  // Wait until the clock catches up, bounded by the maximum wait
+	RegressionReuse = flake.RegressionReuse // Keep issuing from the last-seen timestamp while incrementing randomness
+)
+
+const (
+	DefaultMaxDrift = flake.DefaultMaxDrift // Default maximum drift of a borrowed timestamp ahead of the clock
+	DefaultMaxWait  = flake.DefaultMaxWait  // Default maximum wait for the clock to catch up or to reach the next millisecond
+)
+
+// Stats holds counters of the paths taken by a Generator
+type Stats = flake.Stats
+
+// Generator generates Ulid-Flakes with its own configuration and monotonic state.
+// A Generator is safe for concurrent use by multiple goroutines.
+type Generator struct {
+	generator *flake.Generator
+}
+
+// Option defines the type for functional options
+type Option func(*config) error
+
+type config struct {
+	flake.Config
+}
+
+// defaultGenerator backs the package-level functions
+var defaultGenerator = mustNewGenerator()
+
+// newConfig creates a configuration from the default values and the given options
+func newConfig(opts ...Option) (*config, error) {
+	cfg := &config{
+		Config: flake.Config{
+			Layout:           layout,
+			Epoch:            time.Unix(DefaultEpochSec, 0).UTC(),
+			EntropySize:      MinEntropySize,
+			OverflowPolicy:   OverflowFail,
+			MaxDrift:         DefaultMaxDrift,
+			RegressionPolicy: RegressionFail,
+			MaxWait:          DefaultMaxWait,
+			Clock:            SystemClock{},
+			EntropySource:    NewCryptoSource(),
+			ReserveAhead:     DefaultReserveAhead,
+		},
+	}
+
+	for _, opt := range opts {
+		err := opt(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// NewGenerator creates a new Generator configured with functional options
+func NewGenerator(opts ...Option) (*Generator, error) {
+	cfg, err := newConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	generator, err := flake.NewGenerator(cfg.Config)
+	if err != nil {
+		return nil, err
+	}
+	return &Generator{generator: generator}, nil
+}
+
+// mustNewGenerator creates a Generator with the default configuration
+func mustNewGenerator() *Generator {
+	g, err := NewGenerator()
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// SetConfig sets the configuration values of the generator with functional options
+func (g *Generator) SetConfig(opts ...Option) error {
+	cfg, err := newConfig(opts...)
+	if err != nil {
+		return err
+	}
+	return g.generator.SetConfig(cfg.Config)
+}
+
+// New generates a new Ulid-Flake with the generator's entropy size
+func (g *Generator) New() (*UlidFlake, error) {
+	id, err := g.NewID()
+	if err != nil {
+		return nil, err
+	}
+	return NewUlidFlake(int64(id))
+}
+
+// NewID generates a new Ulid-Flake as an ID without allocating
+func (g *Generator) NewID() (ID, error) {
+	id, err := g.generator.NewID()
+	return ID(id), err
+}
+
+// Stats returns a snapshot of the generator's counters
+func (g *Generator) Stats() Stats {
+	return g.generator.Stats()
+}
+
+// Default returns the default generator backing the package-level functions
+func Default() *Generator {
+	return defaultGenerator
+}
+
+// New generates a new Ulid-Flake with the default generator
+func New() (*UlidFlake, error) {
+	return defaultGenerator.New()
+}
+
+// SetConfig sets the configuration values of the default generator with functional options
+func SetConfig(opts ...Option) error {
+	return defaultGenerator.SetConfig(opts...)
+}
+
+// WithEpochTime sets the custom epoch time
+func WithEpochTime(epoch time.Time) Option {
+	return func(cfg *config) error {
+		cfg.Epoch = epoch
+		return nil
+	}
+}
+
+// WithOverflowPolicy sets the behavior when the randomness is exhausted within a millisecond
+func WithOverflowPolicy(policy OverflowPolicy) Option {
+	return func(cfg *config) error {
+		if policy < OverflowFail || policy > OverflowBorrow {
+			return ErrInvalidConfig
+		}
+		cfg.OverflowPolicy = policy
+		return nil
+	}
+}
+
+// WithMaxDrift sets how far ahead of the clock a borrowed timestamp may run
+func WithMaxDrift(drift time.Duration) Option {
+	return func(cfg *config) error {
+		if drift < 0 {
+			return ErrInvalidConfig
+		}
+		cfg.MaxDrift = drift
+		return nil
+	}
+}
+
+// WithRegressionPolicy sets the behavior when the clock moves backwards
+func WithRegressionPolicy(policy RegressionPolicy) Option {
+	return func(cfg *config) error {
+		if policy < RegressionFail || policy > RegressionReuse {
+			return ErrInvalidConfig
+		}
+		cfg.RegressionPolicy = policy
+		return nil
+	}
+}
+
+// WithMaxWait sets how long to wait for the clock to catch up after a regression or to reach the next millisecond on overflow
+func WithMaxWait(wait time.Duration) Option {
+	return func(cfg *config) error {
+		if wait < 0 {
+			return ErrInvalidConfig
+		}
+		cfg.MaxWait = wait
+		return nil
+	}
+}
+
+// WithClock sets the clock used to read the current time
+func WithClock(clock Clock) Option {
+	return func(cfg *config) error {
+		if clock == nil {
+			return ErrInvalidConfig
+		}
+		cfg.Clock = clock
+		return nil
+	}
+}
+
+// WithEntropySource sets the source of random bytes for the randomness and entropy
+func WithEntropySource(source EntropySource) Option {
+	return func(cfg *config) error {
+		if source == nil {
+			return ErrInvalidConfig
+		}
+		cfg.EntropySource = source
+		return nil
+	}
+}
+
+// WithEntropySize sets the custom entropy size
+func WithEntropySize(entropy int) Option {
+	return func(cfg *config) error {
+		if entropy < MinEntropySize || entropy > MaxEntropySize {
+			return ErrInvalidEntropy
+		}
+		cfg.EntropySize = entropy
+		return nil
+	}
+}
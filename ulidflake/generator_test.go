@@ -0,0 +1,366 @@
+package ulidflake
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGenerator(t *testing.T) {
+	type args struct {
+		opts []Option
+	}
+	tests := []struct {
+		name            string
+		args            args
+		wantEpochTime   time.Time
+		wantEntropySize int
+		wantErr         bool
+	}{
+		{
+			name:            "default configuration",
+			args:            args{},
+			wantEpochTime:   time.Unix(DefaultEpochSec, 0).UTC(),
+			wantEntropySize: MinEntropySize,
+			wantErr:         false,
+		},
+		{
+			name: "custom configuration",
+			args: args{
+				opts: []Option{
+					WithEpochTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+					WithEntropySize(MaxEntropySize),
+				},
+			},
+			wantEpochTime:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantEntropySize: MaxEntropySize,
+			wantErr:         false,
+		},
+		{
+			name: "invalid entropy size",
+			args: args{
+				opts: []Option{
+					WithEntropySize(MaxEntropySize + 1),
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewGenerator(tt.args.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewGenerator() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				assert.Nil(t, got)
+				return
+			}
+			assert.Equal(t, tt.wantEpochTime, got.EpochTime())
+			assert.Equal(t, tt.wantEntropySize, got.generator.Config().EntropySize)
+		})
+	}
+}
+
+func TestGenerator_SetConfig(t *testing.T) {
+	g, err := NewGenerator(WithEntropySize(2))
+	assert.Nil(t, err)
+
+	err = g.SetConfig(WithEntropySize(MaxEntropySize + 1))
+	assert.ErrorIs(t, err, ErrInvalidEntropy)
+	assert.Equal(t, 2, g.generator.Config().EntropySize)
+
+	err = g.SetConfig(WithEpochTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Nil(t, err)
+	assert.Equal(t, MinEntropySize, g.generator.Config().EntropySize)
+	assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), g.EpochTime())
+
+	assert.Equal(t, int64(DefaultEpochSec), defaultGenerator.EpochTime().Unix())
+}
+
+func TestGenerator_New(t *testing.T) {
+	epoch := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	g, err := NewGenerator(WithEpochTime(epoch))
+	assert.Nil(t, err)
+
+	got, err := g.New()
+	assert.Nil(t, err)
+	assert.Len(t, got.String(), UlidFlakeLen)
+	assert.InDelta(t, time.Since(epoch).Milliseconds(), got.Timestamp(), 1000)
+
+	fromDefault, err := New()
+	assert.Nil(t, err)
+	assert.Greater(t, got.Timestamp(), fromDefault.Timestamp())
+}
+
+func TestGenerator_IndependentState(t *testing.T) {
+	// a constant entropy keeps the increments of the default policy far from the maximum randomness
+	g1, err := NewGenerator(WithEpochTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)), WithEntropySource(constantReader(1)))
+	assert.Nil(t, err)
+	g2, err := NewGenerator(WithEntropySource(constantReader(1)))
+	assert.Nil(t, err)
+
+	var previous1, previous2 int64
+	for i := 0; i < 10; i++ {
+		id1, err := g1.New()
+		assert.Nil(t, err)
+		id2, err := g2.New()
+		assert.Nil(t, err)
+		assert.Greater(t, id1.Int(), previous1)
+		assert.Greater(t, id2.Int(), previous2)
+		assert.NotEqual(t, id1.Timestamp(), id2.Timestamp())
+		previous1, previous2 = id1.Int(), id2.Int()
+	}
+}
+
+func TestGenerator_OverflowPolicy(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC)
+	tests := []struct {
+		name          string
+		policy        OverflowPolicy
+		wantTimestamp int64
+		wantClock     time.Time
+		wantErr       error
+	}{
+		{
+			name:    "error",
+			policy:  OverflowFail,
+			wantErr: ErrOverflow,
+		},
+		{
+			name:          "wait until the next millisecond",
+			policy:        OverflowWait,
+			wantTimestamp: 1001,
+			wantClock:     start.Add(time.Millisecond),
+		},
+		{
+			name:          "borrow from the next millisecond",
+			policy:        OverflowBorrow,
+			wantTimestamp: 1001,
+			wantClock:     start,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewManualClock(start)
+			// a saturated entropy gives the first Ulid-Flake the maximum randomness, so that the next one overflows
+			g, err := NewGenerator(WithClock(clock), WithOverflowPolicy(tt.policy), WithEntropySource(constantReader(0xFF)))
+			assert.Nil(t, err)
+
+			first, err := g.New()
+			assert.Nil(t, err)
+			assert.Equal(t, int64(1000), first.Timestamp())
+
+			got, err := g.New()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantTimestamp, got.Timestamp())
+			assert.Greater(t, got.Int(), first.Int())
+			assert.Equal(t, tt.wantClock, clock.Now())
+		})
+	}
+}
+
+func TestGenerator_OverflowWaitMonotonic(t *testing.T) {
+	// the randomness is exhausted within a millisecond, so the generator waits for the next one instead of failing
+	g, err := NewGenerator(WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+	previous, err := g.New()
+	assert.Nil(t, err)
+	for i := 0; i < 10000; i++ {
+		got, err := g.New()
+		assert.Nil(t, err)
+		assert.Greater(t, got.Int(), previous.Int())
+		previous = got
+	}
+}
+
+func TestGenerator_OverflowBorrowMaxDrift(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC))
+	// a saturated entropy exhausts the randomness with every Ulid-Flake, so that each one borrows a millisecond
+	g, err := NewGenerator(WithClock(clock), WithOverflowPolicy(OverflowBorrow), WithMaxDrift(2*time.Millisecond),
+		WithEntropySource(constantReader(0xFF)))
+	assert.Nil(t, err)
+
+	previous, err := g.New()
+	assert.Nil(t, err)
+	for _, wantTimestamp := range []int64{1001, 1002} {
+		got, err := g.New()
+		assert.Nil(t, err)
+		assert.Equal(t, wantTimestamp, got.Timestamp())
+		assert.Greater(t, got.Int(), previous.Int())
+		previous = got
+	}
+
+	_, err = g.New()
+	assert.ErrorIs(t, err, ErrOverflow)
+
+	// the clock is still behind the borrowed timestamp, which is reused before borrowing the next one
+	clock.Advance(time.Millisecond)
+	got, err := g.New()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1003), got.Timestamp())
+	assert.Greater(t, got.Int(), previous.Int())
+}
+
+func TestWithOverflowPolicy(t *testing.T) {
+	_, err := NewGenerator(WithOverflowPolicy(OverflowBorrow + 1))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+
+	_, err = NewGenerator(WithMaxDrift(-time.Millisecond))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestGenerator_RegressionPolicy(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		policy     RegressionPolicy
+		regression time.Duration
+		wantClock  time.Time
+		wantStats  Stats
+		wantErr    error
+	}{
+		{
+			name:       "error",
+			policy:     RegressionFail,
+			regression: 5 * time.Millisecond,
+			wantClock:  start.Add(-5 * time.Millisecond),
+			wantStats:  Stats{Generated: 1, Regressions: 1},
+			wantErr:    ErrInvalidTimestamp,
+		},
+		{
+			name:       "wait until the clock catches up",
+			policy:     RegressionWait,
+			regression: 5 * time.Millisecond,
+			wantClock:  start,
+			wantStats:  Stats{Generated: 2, Regressions: 1, RegressionWaits: 1},
+		},
+		{
+			name:       "wait longer than the maximum wait",
+			policy:     RegressionWait,
+			regression: DefaultMaxWait + time.Millisecond,
+			wantClock:  start.Add(-DefaultMaxWait - time.Millisecond),
+			wantStats:  Stats{Generated: 1, Regressions: 1},
+			wantErr:    ErrInvalidTimestamp,
+		},
+		{
+			name:       "reuse the last-seen timestamp",
+			policy:     RegressionReuse,
+			regression: time.Hour,
+			wantClock:  start.Add(-time.Hour),
+			wantStats:  Stats{Generated: 2, Regressions: 1, RegressionReuses: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewManualClock(start)
+			// a constant entropy increments the randomness by one, so that the increments after the regression cannot exhaust it
+			g, err := NewGenerator(WithClock(clock), WithRegressionPolicy(tt.policy), WithEntropySource(constantReader(1)))
+			assert.Nil(t, err)
+
+			first, err := g.New()
+			assert.Nil(t, err)
+
+			clock.Rewind(tt.regression)
+			got, err := g.New()
+			assert.Equal(t, tt.wantClock, clock.Now())
+			assert.Equal(t, tt.wantStats, g.Stats())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, first.Timestamp(), got.Timestamp())
+			assert.Greater(t, got.Int(), first.Int())
+		})
+	}
+}
+
+func TestGenerator_Stats(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC))
+	// a saturated entropy gives the first Ulid-Flake the maximum randomness, so that the next one overflows
+	g, err := NewGenerator(WithClock(clock), WithOverflowPolicy(OverflowWait), WithEntropySource(constantReader(0xFF)))
+	assert.Nil(t, err)
+
+	_, err = g.New()
+	assert.Nil(t, err)
+	_, err = g.New()
+	assert.Nil(t, err)
+
+	assert.Equal(t, Stats{Generated: 2, Overflows: 1, OverflowWaits: 1}, g.Stats())
+}
+
+func TestGenerator_FrozenClock(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC))
+	g, err := NewGenerator(
+		WithClock(clock),
+		WithOverflowPolicy(OverflowWait),
+		WithRegressionPolicy(RegressionWait),
+		WithMaxWait(10*time.Millisecond),
+		// a saturated entropy gives the first Ulid-Flake the maximum randomness, so that the next one overflows
+		WithEntropySource(constantReader(0xFF)),
+	)
+	assert.Nil(t, err)
+
+	_, err = g.New()
+	assert.Nil(t, err)
+
+	clock.Freeze()
+	_, err = g.New()
+	assert.ErrorIs(t, err, ErrOverflow)
+
+	clock.Rewind(5 * time.Millisecond)
+	_, err = g.New()
+	assert.ErrorIs(t, err, ErrInvalidTimestamp)
+
+	clock.Unfreeze()
+	got, err := g.New()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1001), got.Timestamp())
+}
+
+func TestGenerator_SameMillisecond(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC))
+	// start from the minimal randomness so that 50 increments of at most 255 cannot overflow
+	source := io.MultiReader(bytes.NewReader(make([]byte, MaxEntropySize+1)), NewSeededSource(1))
+	g, err := NewGenerator(WithClock(clock), WithEntropySize(1), WithEntropySource(source))
+	assert.Nil(t, err)
+
+	first, err := g.New()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(MinRandomness), first.Randomness())
+
+	previousRandomness := first.Randomness()
+	for i := 0; i < 50; i++ {
+		got, err := g.New()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1000), got.Timestamp())
+		increment := got.Randomness() - previousRandomness
+		assert.GreaterOrEqual(t, increment, int64(1))
+		assert.LessOrEqual(t, increment, int64(1<<8-1))
+		previousRandomness = got.Randomness()
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	_, err := NewGenerator(WithClock(nil))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestWithRegressionPolicy(t *testing.T) {
+	_, err := NewGenerator(WithRegressionPolicy(RegressionReuse + 1))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+
+	_, err = NewGenerator(WithMaxWait(-time.Millisecond))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
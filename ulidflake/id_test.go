@@ -0,0 +1,366 @@
+package ulidflake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestID_Compare(t *testing.T) {
+	tests := []struct {
+		name      string
+		a         ID
+		b         ID
+		want      int
+		wantLess  bool
+		wantEqual bool
+	}{
+		{
+			name:      "less",
+			a:         Zero,
+			b:         MaxInt,
+			want:      -1,
+			wantLess:  true,
+			wantEqual: false,
+		},
+		{
+			name:      "equal",
+			a:         14246757444195114,
+			b:         14246757444195114,
+			want:      0,
+			wantLess:  false,
+			wantEqual: true,
+		},
+		{
+			name:      "greater",
+			a:         MaxInt,
+			b:         Zero,
+			want:      1,
+			wantLess:  false,
+			wantEqual: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Compare(tt.a, tt.b))
+			assert.Equal(t, tt.want, tt.a.Compare(tt.b))
+			assert.Equal(t, tt.wantLess, tt.a.Less(tt.b))
+			assert.Equal(t, tt.wantEqual, tt.a.Equal(tt.b))
+			assert.Equal(t, tt.wantEqual, tt.a == tt.b)
+		})
+	}
+}
+
+func TestID_IsZero(t *testing.T) {
+	var id ID
+	assert.True(t, id.IsZero())
+	assert.Equal(t, Zero, id)
+	assert.False(t, ID(1).IsZero())
+}
+
+func TestID_Accessors(t *testing.T) {
+	u := &UlidFlake{value: MaxInt}
+	id := u.ID()
+	assert.Equal(t, ID(MaxInt), id)
+	assert.Equal(t, u.String(), id.String())
+	assert.Equal(t, u.Int(), id.Int())
+	assert.Equal(t, u.Hex(), id.Hex())
+	assert.Equal(t, u.Bin(), id.Bin())
+	assert.Equal(t, u.Timestamp(), id.Timestamp())
+	assert.Equal(t, u.Randomness(), id.Randomness())
+	assert.Equal(t, u, id.UlidFlake())
+}
+
+func TestID_SortAndMapKey(t *testing.T) {
+	g, err := NewGenerator(WithClock(NewManualClock(time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC))), WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+
+	var ids []ID
+	seen := map[ID]bool{}
+	for i := 0; i < 100; i++ {
+		id, err := g.NewID()
+		assert.Nil(t, err)
+		ids = append(ids, id)
+		seen[id] = true
+	}
+	assert.Len(t, seen, len(ids))
+
+	shuffled := slices.Clone(ids)
+	slices.Reverse(shuffled)
+	slices.SortFunc(shuffled, Compare)
+	assert.Equal(t, ids, shuffled)
+}
+
+func TestNewID(t *testing.T) {
+	got, err := NewID()
+	assert.Nil(t, err)
+	assert.False(t, got.IsZero())
+	assert.Len(t, got.String(), UlidFlakeLen)
+
+	g, err := NewGenerator(WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := g.NewID(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	assert.Equal(t, float64(0), allocs)
+}
+
+func TestParseID(t *testing.T) {
+	got, err := ParseID("00CMXB6TAK4SA")
+	assert.Nil(t, err)
+	assert.Equal(t, ID(14246757444195114), got)
+
+	got, err = ParseID("8000000000000")
+	assert.NotNil(t, err)
+	assert.Equal(t, Zero, got)
+
+	got, err = ParseID("00cmxb6tak4sa")
+	assert.Nil(t, err)
+	assert.Equal(t, ID(14246757444195114), got)
+}
+
+func TestParseIDStrict(t *testing.T) {
+	got, err := ParseIDStrict("00CMXB6TAK4SA")
+	assert.Nil(t, err)
+	assert.Equal(t, ID(14246757444195114), got)
+
+	got, err = ParseIDStrict("00cmxb6tak4sa")
+	assert.ErrorIs(t, err, ErrInvalidULID)
+	assert.Equal(t, Zero, got)
+}
+
+func TestID_MarshalJSON(t *testing.T) {
+	in := map[string]ID{"id": 14246757444195114}
+	data, err := json.Marshal(in)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"id":"00CMXB6TAK4SA"}`, string(data))
+
+	var out map[string]ID
+	assert.Nil(t, json.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+}
+
+func TestID_Append(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      ID
+		wantStr string
+		wantHex string
+		wantBin string
+	}{
+		{
+			name:    "minimal value",
+			id:      0,
+			wantStr: "0000000000000",
+			wantHex: "0x0",
+			wantBin: "0b0",
+		},
+		{
+			name:    "maximal value",
+			id:      MaxInt,
+			wantStr: "7ZZZZZZZZZZZZ",
+			wantHex: "0x7FFFFFFFFFFFFFFF",
+			wantBin: "0b" + strings.Repeat("1", IntSize),
+		},
+		{
+			name:    "regular value",
+			id:      14246757444195114,
+			wantStr: "00CMXB6TAK4SA",
+			wantHex: "0x329D59B4A9932A",
+			wantBin: "0b110010100111010101100110110100101010011001001100101010",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix := []byte("id=")
+			assert.Equal(t, "id="+tt.wantStr, string(tt.id.AppendString(prefix)))
+			assert.Equal(t, "id="+tt.wantHex, string(tt.id.AppendHex(prefix)))
+			assert.Equal(t, "id="+tt.wantBin, string(tt.id.AppendBin(prefix)))
+			assert.Equal(t, tt.wantStr, tt.id.String())
+			assert.Equal(t, tt.wantHex, tt.id.Hex())
+			assert.Equal(t, tt.wantBin, tt.id.Bin())
+			assert.Equal(t, legacyHex(int64(tt.id)), tt.id.Hex())
+			assert.Equal(t, legacyBin(int64(tt.id)), tt.id.Bin())
+
+			text, err := tt.id.AppendText(prefix)
+			assert.Nil(t, err)
+			assert.Equal(t, "id="+tt.wantStr, string(text))
+
+			buf := make([]byte, UlidFlakeLen+1)
+			n, err := tt.id.MarshalTo(buf)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantStr, string(buf[:n]))
+		})
+	}
+
+	_, err := ID(0).MarshalTo(make([]byte, UlidFlakeLen-1))
+	assert.ErrorIs(t, err, io.ErrShortBuffer)
+}
+
+func TestID_AppendAllocs(t *testing.T) {
+	id := ID(14246757444195114)
+	buf := make([]byte, 0, binLen)
+	text := []byte("00CMXB6TAK4SA")
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = id.AppendString(buf[:0])
+		buf = id.AppendHex(buf[:0])
+		buf = id.AppendBin(buf[:0])
+		if _, err := id.MarshalTo(buf[:cap(buf)]); err != nil {
+			t.Fatal(err)
+		}
+		var parsed ID
+		if err := parsed.UnmarshalText(text); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ParseID("00CMXB6TAK4SA"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	assert.Equal(t, float64(0), allocs)
+}
+
+// legacyEncodeBase32 is the former encoder, kept to benchmark against
+func legacyEncodeBase32(value int64, length int) string {
+	encoded := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		encoded[i] = encoding[value&31]
+		value >>= 5
+	}
+	return string(encoded)
+}
+
+// legacyDecodeBase32 is the former decoder with a linear scan of the alphabet, kept to benchmark against
+func legacyDecodeBase32(encoded string) (int64, error) {
+	var value int64
+	for _, c := range encoded {
+		idx := -1
+		for i, encChar := range encoding {
+			if c == rune(encChar) {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			return 0, ErrInvalidULID
+		}
+		value = value*32 + int64(idx)
+	}
+	return value, nil
+}
+
+// legacyHex is the former hexadecimal formatter, kept to benchmark against
+func legacyHex(value int64) string {
+	return "0x" + fmt.Sprintf("%X", value)
+}
+
+// legacyBin is the former binary formatter, kept to benchmark against
+func legacyBin(value int64) string {
+	return "0b" + fmt.Sprintf("%b", value)
+}
+
+func BenchmarkEncode(b *testing.B) {
+	id := ID(14246757444195114)
+	b.Run("legacy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = legacyEncodeBase32(int64(id), UlidFlakeLen)
+		}
+	})
+	b.Run("String", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = id.String()
+		}
+	})
+	b.Run("AppendString", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, 0, UlidFlakeLen)
+		for i := 0; i < b.N; i++ {
+			buf = id.AppendString(buf[:0])
+		}
+	})
+}
+
+func BenchmarkDecode(b *testing.B) {
+	s := "00CMXB6TAK4SA"
+	text := []byte(s)
+	b.Run("legacy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := legacyDecodeBase32(s); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ParseID", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseID(s); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("UnmarshalText", func(b *testing.B) {
+		b.ReportAllocs()
+		var id ID
+		for i := 0; i < b.N; i++ {
+			if err := id.UnmarshalText(text); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkHex(b *testing.B) {
+	id := ID(14246757444195114)
+	b.Run("legacy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = legacyHex(int64(id))
+		}
+	})
+	b.Run("Hex", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = id.Hex()
+		}
+	})
+	b.Run("AppendHex", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, 0, hexLen)
+		for i := 0; i < b.N; i++ {
+			buf = id.AppendHex(buf[:0])
+		}
+	})
+}
+
+func BenchmarkBin(b *testing.B) {
+	id := ID(14246757444195114)
+	b.Run("legacy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = legacyBin(int64(id))
+		}
+	})
+	b.Run("Bin", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = id.Bin()
+		}
+	})
+	b.Run("AppendBin", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, 0, binLen)
+		for i := 0; i < b.N; i++ {
+			buf = id.AppendBin(buf[:0])
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package ulidflake
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUlidFlake_MarshalJSON(t *testing.T) {
+	type payload struct {
+		ID      UlidFlake  `json:"id"`
+		Ptr     *UlidFlake `json:"ptr"`
+		Number  JSONNumber `json:"number"`
+		Missing *UlidFlake `json:"missing"`
+	}
+	in := payload{
+		ID:     UlidFlake{value: MaxInt},
+		Ptr:    &UlidFlake{value: 0},
+		Number: JSONNumber{UlidFlake{value: 14246757444195114}},
+	}
+
+	data, err := json.Marshal(in)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"id":"7ZZZZZZZZZZZZ","ptr":"0000000000000","number":14246757444195114,"missing":null}`, string(data))
+
+	var out payload
+	err = json.Unmarshal(data, &out)
+	assert.Nil(t, err)
+	assert.Equal(t, in, out)
+}
+
+func TestUlidFlake_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    UlidFlake
+		wantErr bool
+	}{
+		{
+			name: "Base32 string",
+			data: `"00CMXB6TAK4SA"`,
+			want: UlidFlake{value: 14246757444195114},
+		},
+		{
+			name: "number",
+			data: `14246757444195114`,
+			want: UlidFlake{value: 14246757444195114},
+		},
+		{
+			name: "null",
+			data: `null`,
+			want: UlidFlake{value: 0},
+		},
+		{
+			name:    "invalid Base32 string",
+			data:    `"00CMXB6TAK4S"`,
+			wantErr: true,
+		},
+		{
+			name:    "negative number",
+			data:    `-1`,
+			wantErr: true,
+		},
+		{
+			name:    "float number",
+			data:    `1.5`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got UlidFlake
+			err := got.UnmarshalJSON([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UlidFlake.UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUlidFlake_MarshalText(t *testing.T) {
+	in := UlidFlake{value: 14246757444195114}
+	text, err := in.MarshalText()
+	assert.Nil(t, err)
+	assert.Equal(t, "00CMXB6TAK4SA", string(text))
+
+	var out UlidFlake
+	assert.Nil(t, out.UnmarshalText(text))
+	assert.Equal(t, in, out)
+
+	assert.ErrorIs(t, out.UnmarshalText([]byte("00CMXB6TAK4S!")), ErrInvalidULID)
+	assert.Equal(t, in, out)
+}
+
+func TestUlidFlake_MarshalBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   int64
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name:  "minimal value",
+			value: 0,
+			want:  []byte{0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:  "maximal value",
+			value: MaxInt,
+			want:  []byte{127, 255, 255, 255, 255, 255, 255, 255},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := UlidFlake{value: tt.value}
+			data, err := in.MarshalBinary()
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, data)
+
+			var out UlidFlake
+			assert.Nil(t, out.UnmarshalBinary(data))
+			assert.Equal(t, in, out)
+		})
+	}
+
+	var out UlidFlake
+	assert.ErrorIs(t, out.UnmarshalBinary([]byte{0, 0, 0, 0, 0, 0, 0}), ErrInvalidULID)
+	assert.ErrorIs(t, out.UnmarshalBinary([]byte{128, 0, 0, 0, 0, 0, 0, 0}), ErrOverflow)
+}
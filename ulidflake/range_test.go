@@ -0,0 +1,102 @@
+package ulidflake
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinMaxForTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		t       time.Time
+		wantMin ID
+		wantMax ID
+		wantErr bool
+	}{
+		{
+			name:    "epoch time",
+			t:       time.Unix(DefaultEpochSec, 0),
+			wantMin: 0,
+			wantMax: MaxRandomness,
+			wantErr: false,
+		},
+		{
+			name:    "sub-millisecond precision is truncated",
+			t:       time.Unix(DefaultEpochSec, int64(1500*time.Microsecond)),
+			wantMin: 1 << 20,
+			wantMax: 1<<20 | MaxRandomness,
+			wantErr: false,
+		},
+		{
+			name:    "maximal timestamp",
+			t:       time.Unix(DefaultEpochSec, 0).Add(MaxTimestamp * time.Millisecond),
+			wantMin: MaxTimestamp << 20,
+			wantMax: MaxInt,
+			wantErr: false,
+		},
+		{
+			name:    "before epoch time",
+			t:       time.Unix(DefaultEpochSec, 0).Add(-time.Millisecond),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotMin, err := MinForTime(tt.t)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MinForTime() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			gotMax, err := MaxForTime(tt.t)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MaxForTime() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.wantMin, gotMin)
+			assert.Equal(t, tt.wantMax, gotMax)
+		})
+	}
+}
+
+func TestGenerator_TimeRange(t *testing.T) {
+	epoch := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(epoch.Add(time.Hour))
+	g, err := NewGenerator(WithEpochTime(epoch), WithClock(clock))
+	assert.Nil(t, err)
+
+	before, err := g.NewID()
+	assert.Nil(t, err)
+	clock.Advance(time.Millisecond)
+	first, err := g.NewID()
+	assert.Nil(t, err)
+	clock.Advance(time.Second)
+	last, err := g.NewID()
+	assert.Nil(t, err)
+	clock.Advance(time.Millisecond)
+	after, err := g.NewID()
+	assert.Nil(t, err)
+
+	r, err := g.TimeRange(g.Time(first), g.Time(last))
+	assert.Nil(t, err)
+	assert.Equal(t, g.Time(first), g.Time(r.Min))
+	assert.Equal(t, g.Time(last), g.Time(r.Max))
+	assert.False(t, r.Contains(before))
+	assert.True(t, r.Contains(first))
+	assert.True(t, r.Contains(last))
+	assert.False(t, r.Contains(after))
+
+	_, err = g.TimeRange(g.Time(last), g.Time(first))
+	assert.ErrorIs(t, err, ErrInvalidTimestamp)
+
+	_, err = g.TimeRange(epoch.Add(-time.Millisecond), g.Time(last))
+	assert.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestTimeRange(t *testing.T) {
+	from := time.Unix(DefaultEpochSec, 0)
+	r, err := TimeRange(from, from.Add(time.Millisecond))
+	assert.Nil(t, err)
+	assert.Equal(t, Range{Min: 0, Max: 1<<20 | MaxRandomness}, r)
+}
@@ -0,0 +1,134 @@
+package ulidflake
+
+import (
+	"database/sql/driver"
+	"strconv"
+)
+
+// SQLString wraps a UlidFlake to be stored in the database as a 13-character Base32 string
+// (e.g. in a CHAR(13) column) instead of an integer
+type SQLString struct {
+	UlidFlake
+}
+
+// NullUlidFlake represents a UlidFlake that may be null in the database
+type NullUlidFlake struct {
+	UlidFlake UlidFlake
+	Valid     bool // Valid is true if UlidFlake is not NULL
+}
+
+// Scan implements the sql.Scanner interface, accepting an integer,
+// or a Base32 or decimal string as string or []byte.
+// A string of UlidFlakeLen characters is always decoded as Base32, and any other string of digits only as decimal.
+func (id *ID) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case int64:
+		parsed, err := FromInt(v)
+		if err != nil {
+			return err
+		}
+		*id = parsed.ID()
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return ErrInvalidULID
+	}
+
+	if len(s) == UlidFlakeLen || !isDecimal(s) {
+		return id.UnmarshalText([]byte(s))
+	}
+	value, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return ErrInvalidULID
+	}
+	parsed, err := FromInt(value)
+	if err != nil {
+		return err
+	}
+	*id = parsed.ID()
+	return nil
+}
+
+// isDecimal reports whether the string is a non-empty run of decimal digits
+func isDecimal(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Value implements the driver.Valuer interface, storing the ID as an integer
+func (id ID) Value() (driver.Value, error) {
+	return id.Int(), nil
+}
+
+// Scan implements the sql.Scanner interface, accepting the same formats as ID.Scan
+func (u *UlidFlake) Scan(src any) error {
+	id := u.ID()
+	if err := id.Scan(src); err != nil {
+		return err
+	}
+	u.value = int64(id)
+	return nil
+}
+
+// Value implements the driver.Valuer interface, storing the UlidFlake as an integer
+func (u UlidFlake) Value() (driver.Value, error) {
+	return u.ID().Value()
+}
+
+// Value implements the driver.Valuer interface, storing the UlidFlake as a Base32 string
+func (s SQLString) Value() (driver.Value, error) {
+	return s.String(), nil
+}
+
+// Scan implements the sql.Scanner interface, decoding a string as Base32 since the UlidFlake is stored as one,
+// and accepting an integer like UlidFlake.Scan
+func (s *SQLString) Scan(src any) error {
+	var text []byte
+	switch v := src.(type) {
+	case string:
+		text = []byte(v)
+	case []byte:
+		text = v
+	default:
+		return s.UlidFlake.Scan(src)
+	}
+	id := s.ID()
+	if err := id.UnmarshalText(text); err != nil {
+		return err
+	}
+	s.value = int64(id)
+	return nil
+}
+
+// Scan implements the sql.Scanner interface, accepting NULL in addition to the UlidFlake formats
+func (n *NullUlidFlake) Scan(src any) error {
+	if src == nil {
+		n.UlidFlake, n.Valid = UlidFlake{}, false
+		return nil
+	}
+	if err := n.UlidFlake.Scan(src); err != nil {
+		n.Valid = false
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// Value implements the driver.Valuer interface, storing NULL when the UlidFlake is not valid
+func (n NullUlidFlake) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.UlidFlake.Value()
+}
@@ -0,0 +1,112 @@
+package ulidflake
+
+import "time"
+
+// FromOption defines the type for functional options of the From constructors
+type FromOption func(*components) error
+
+type components struct {
+	randomness int64
+}
+
+// EpochTime returns the epoch time of the generator
+func (g *Generator) EpochTime() time.Time {
+	return g.generator.Config().Epoch
+}
+
+// Time returns the time the ID was generated at, in UTC, against the generator's epoch
+func (g *Generator) Time(id ID) time.Time {
+	return g.EpochTime().Add(time.Duration(id.Timestamp()) * time.Millisecond).UTC()
+}
+
+// UnixMilli returns the Unix time in milliseconds the ID was generated at, against the generator's epoch
+func (g *Generator) UnixMilli(id ID) int64 {
+	return g.EpochTime().UnixMilli() + id.Timestamp()
+}
+
+// Age returns the time elapsed between the generation of the ID and now, against the generator's epoch
+func (g *Generator) Age(id ID, now time.Time) time.Duration {
+	return now.Sub(g.Time(id))
+}
+
+// Time returns the time the ID was generated at, in UTC, against the default generator's epoch
+func (id ID) Time() time.Time {
+	return defaultGenerator.Time(id)
+}
+
+// UnixMilli returns the Unix time in milliseconds the ID was generated at, against the default generator's epoch
+func (id ID) UnixMilli() int64 {
+	return defaultGenerator.UnixMilli(id)
+}
+
+// Age returns the time elapsed between the generation of the ID and now, against the default generator's epoch
+func (id ID) Age(now time.Time) time.Duration {
+	return defaultGenerator.Age(id, now)
+}
+
+// Time returns the time the UlidFlake was generated at, in UTC, against the default generator's epoch
+func (u *UlidFlake) Time() time.Time {
+	return u.ID().Time()
+}
+
+// UnixMilli returns the Unix time in milliseconds the UlidFlake was generated at, against the default generator's epoch
+func (u *UlidFlake) UnixMilli() int64 {
+	return u.ID().UnixMilli()
+}
+
+// Age returns the time elapsed between the generation of the UlidFlake and now, against the default generator's epoch
+func (u *UlidFlake) Age(now time.Time) time.Duration {
+	return u.ID().Age(now)
+}
+
+// FromTime creates a Ulid-Flake instance from a time against the generator's epoch,
+// with a random randomness component unless fixed by the options
+func (g *Generator) FromTime(t time.Time, opts ...FromOption) (*UlidFlake, error) {
+	timestamp, err := generateTimestamp(t.UTC(), g.EpochTime())
+	if err != nil {
+		return nil, err
+	}
+
+	c := &components{randomness: -1}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	if c.randomness < 0 {
+		c.randomness, err = g.generator.NewRandomness()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	signBit := int64(0)
+	combined := (signBit << 63) | layout.Pack(timestamp, 0, c.randomness, 0)
+	return NewUlidFlake(combined)
+}
+
+// FromUnixMilli creates a Ulid-Flake instance from a Unix time in milliseconds against the generator's epoch
+func (g *Generator) FromUnixMilli(unixMilli int64, opts ...FromOption) (*UlidFlake, error) {
+	return g.FromTime(time.UnixMilli(unixMilli), opts...)
+}
+
+// FromTime creates a Ulid-Flake instance from a time against the default generator's epoch
+func FromTime(t time.Time, opts ...FromOption) (*UlidFlake, error) {
+	return defaultGenerator.FromTime(t, opts...)
+}
+
+// FromUnixMilli creates a Ulid-Flake instance from a Unix time in milliseconds against the default generator's epoch
+func FromUnixMilli(unixMilli int64, opts ...FromOption) (*UlidFlake, error) {
+	return defaultGenerator.FromUnixMilli(unixMilli, opts...)
+}
+
+// WithFixedRandomness sets a deterministic randomness component
+func WithFixedRandomness(randomness int64) FromOption {
+	return func(c *components) error {
+		if randomness < MinRandomness || randomness > MaxRandomness {
+			return ErrInvalidRandomness
+		}
+		c.randomness = randomness
+		return nil
+	}
+}
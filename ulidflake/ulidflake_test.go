@@ -1,11 +1,13 @@
 package ulidflake
 
 import (
+	"errors"
 	reflect "reflect"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewUlidFlake(t *testing.T) {
@@ -61,6 +63,12 @@ func TestNewUlidFlake(t *testing.T) {
 	}
 }
 
+func Test_layout(t *testing.T) {
+	assert.Nil(t, layout.Validate())
+	assert.Equal(t, int64(MaxTimestamp), layout.MaxTimestamp())
+	assert.Equal(t, int64(MaxRandomness), layout.MaxRandomness())
+}
+
 func TestUlidFlake_String(t *testing.T) {
 	type fields struct {
 		value int64
@@ -275,28 +283,28 @@ func TestUlidFlake_bytes(t *testing.T) {
 			fields: fields{
 				value: 0,
 			},
-			want: []byte{0, 0, 0, 0, 0, 0, 0},
+			want: []byte{0, 0, 0, 0, 0, 0, 0, 0},
 		},
 		{
 			name: "maximal value",
 			fields: fields{
 				value: MaxInt,
 			},
-			want: []byte{255, 255, 255, 255, 255, 255, 255},
+			want: []byte{127, 255, 255, 255, 255, 255, 255, 255},
 		},
 		{
 			name: "negative value (overflow, this should never happen, but theoretically possible)",
 			fields: fields{
 				value: -1,
 			},
-			want: []byte{255, 255, 255, 255, 255, 255, 255},
+			want: []byte{255, 255, 255, 255, 255, 255, 255, 255},
 		},
 		{
 			name: "overflow value (this should never happen, but theoretically possible)",
 			fields: fields{
 				value: -1 << IntSize,
 			},
-			want: []byte{0, 0, 0, 0, 0, 0, 0},
+			want: []byte{128, 0, 0, 0, 0, 0, 0, 0},
 		},
 	}
 	for _, tt := range tests {
@@ -363,122 +371,99 @@ func Test_encodeBase32(t *testing.T) {
 	}
 }
 
-func Test_generateTimestamp(t *testing.T) {
+func Test_decodeBase32(t *testing.T) {
 	type args struct {
-		now time.Time
+		encoded string
+		strict  bool
 	}
 	tests := []struct {
 		name    string
 		args    args
 		want    int64
-		wantErr bool
+		wantErr error
 	}{
 		{
-			name: "minimal value",
+			name: "canonical string",
 			args: args{
-				now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				encoded: "00CMXB6TAK4SA",
+				strict:  true,
 			},
-			want:    0,
-			wantErr: false,
+			want: 14246757444195114,
 		},
 		{
-			name: "maximal value",
+			name: "lowercase string",
 			args: args{
-				now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(1<<43-1) * time.Millisecond),
+				encoded: "00cmxb6tak4sa",
+				strict:  false,
 			},
-			want:    1<<43 - 1,
-			wantErr: false,
+			want: 14246757444195114,
 		},
 		{
-			name: "negative value (overflow, this should never happen, but theoretically possible)",
+			name: "aliases O, I and L",
 			args: args{
-				now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(1<<43) * time.Millisecond),
+				encoded: "OoIiLl",
+				strict:  false,
 			},
-			want:    0,
-			wantErr: true,
+			want: 0b00000_00000_00001_00001_00001_00001,
 		},
 		{
-			name: "before epoch time",
+			name: "lowercase string in strict mode",
 			args: args{
-				now: time.Date(2023, 12, 31, 23, 59, 59, 999000000, time.UTC),
+				encoded: "00cmxb6tak4sa",
+				strict:  true,
 			},
-			want:    0,
-			wantErr: true,
+			wantErr: ErrInvalidULID,
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := generateTimestamp(tt.args.now)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("generateTimestamp() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if got != tt.want {
-				t.Errorf("generateTimestamp() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func Test_generateRandomness(t *testing.T) {
-	type args struct {
-		randomFunc func(size int) ([]byte, error)
-	}
-	tests := []struct {
-		name    string
-		args    args
-		want    int64
-		wantErr bool
-	}{
 		{
-			name: "minimal value",
+			name: "aliases in strict mode",
 			args: args{
-				randomFunc: func(size int) ([]byte, error) {
-					return []byte{0, 0, 0}, nil
-				},
+				encoded: "O0CMXB6TAK4SA",
+				strict:  true,
 			},
-			want:    0,
-			wantErr: false,
+			wantErr: ErrInvalidULID,
 		},
 		{
-			name: "maximal value",
+			name: "excluded letter U",
 			args: args{
-				randomFunc: func(size int) ([]byte, error) {
-					return []byte{255, 255, 255}, nil
-				},
+				encoded: "00CMXB6TAK4SU",
+				strict:  false,
 			},
-			want:    1<<20 - 1,
-			wantErr: false,
+			wantErr: ErrInvalidULID,
 		},
 		{
-			name: "overflow, this should never happen, but theoretically possible",
+			name: "leading character above 7",
 			args: args{
-				randomFunc: func(size int) ([]byte, error) {
-					return []byte{255, 255, 255, 255}, nil
-				},
+				encoded: "8000000000000",
+				strict:  false,
 			},
-			want:    1<<20 - 1,
-			wantErr: false,
+			wantErr: ErrOverflow,
+		},
+		{
+			name: "leading lowercase character above 7",
+			args: args{
+				encoded: "zzzzzzzzzzzzz",
+				strict:  false,
+			},
+			wantErr: ErrOverflow,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := generateRandomness(tt.args.randomFunc)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("generateRandomness() error = %v, wantErr %v", err, tt.wantErr)
+			got, err := decodeBase32(tt.args.encoded, tt.args.strict)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("decodeBase32() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if got != tt.want {
-				t.Errorf("generateRandomness() = %v, want %v", got, tt.want)
+				t.Errorf("decodeBase32() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func Test_generateEntropy(t *testing.T) {
+func Test_generateTimestamp(t *testing.T) {
 	type args struct {
-		size       int
-		randomFunc func(size int) ([]byte, error)
+		now time.Time
 	}
 	tests := []struct {
 		name    string
@@ -489,10 +474,7 @@ func Test_generateEntropy(t *testing.T) {
 		{
 			name: "minimal value",
 			args: args{
-				size: 1,
-				randomFunc: func(size int) ([]byte, error) {
-					return []byte{0}, nil
-				},
+				now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
 			},
 			want:    0,
 			wantErr: false,
@@ -500,32 +482,23 @@ func Test_generateEntropy(t *testing.T) {
 		{
 			name: "maximal value",
 			args: args{
-				size: 3,
-				randomFunc: func(size int) ([]byte, error) {
-					return []byte{255, 255, 255}, nil
-				},
+				now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(1<<43-1) * time.Millisecond),
 			},
-			want:    1<<24 - 1,
+			want:    1<<43 - 1,
 			wantErr: false,
 		},
 		{
-			name: "too big size",
+			name: "negative value (overflow, this should never happen, but theoretically possible)",
 			args: args{
-				size: 4,
-				randomFunc: func(size int) ([]byte, error) {
-					return []byte{255, 255, 255, 255}, nil
-				},
+				now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(1<<43) * time.Millisecond),
 			},
 			want:    0,
 			wantErr: true,
 		},
 		{
-			name: "too small size",
+			name: "before epoch time",
 			args: args{
-				size: 0,
-				randomFunc: func(size int) ([]byte, error) {
-					return []byte{}, nil
-				},
+				now: time.Date(2023, 12, 31, 23, 59, 59, 999000000, time.UTC),
 			},
 			want:    0,
 			wantErr: true,
@@ -533,13 +506,13 @@ func Test_generateEntropy(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := generateEntropy(tt.args.size, tt.args.randomFunc)
+			got, err := generateTimestamp(tt.args.now, time.Unix(DefaultEpochSec, 0).UTC())
 			if (err != nil) != tt.wantErr {
-				t.Errorf("generateEntropy() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("generateTimestamp() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if got != tt.want {
-				t.Errorf("generateEntropy() = %v, want %v", got, tt.want)
+				t.Errorf("generateTimestamp() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -622,6 +595,26 @@ func TestParse(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "lowercase value",
+			args: args{
+				ulidFlakeString: "7zzzzzzzzzzzz",
+			},
+			want: &UlidFlake{
+				value: MaxInt,
+			},
+			wantErr: false,
+		},
+		{
+			name: "aliases O, I and L",
+			args: args{
+				ulidFlakeString: "OOOOOOOOOOOIL",
+			},
+			want: &UlidFlake{
+				value: 33,
+			},
+			wantErr: false,
+		},
 		{
 			name: "invalid length 12",
 			args: args{
@@ -653,6 +646,19 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseStrict(t *testing.T) {
+	got, err := ParseStrict("7ZZZZZZZZZZZZ")
+	assert.Nil(t, err)
+	assert.Equal(t, &UlidFlake{value: MaxInt}, got)
+
+	for _, s := range []string{"7zzzzzzzzzzzz", "OOOOOOOOOOOIL", "000000000000"} {
+		_, err = ParseStrict(s)
+		assert.ErrorIs(t, err, ErrInvalidULID, s)
+	}
+	_, err = ParseStrict("8000000000000")
+	assert.ErrorIs(t, err, ErrOverflow)
+}
+
 func TestFromInt(t *testing.T) {
 	type args struct {
 		value int64
@@ -888,17 +894,21 @@ func TestSetConfig(t *testing.T) {
 				t.Errorf("SetConfig() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
-		assert.Equal(t, int64(DefaultEpochSec), epochTime.Unix())
-		assert.Equal(t, 1, entropySize)
+		assert.Equal(t, int64(DefaultEpochSec), defaultGenerator.EpochTime().Unix())
+		assert.Equal(t, 1, defaultGenerator.generator.Config().EntropySize)
 	}
 }
 
 func TestMonotonicallyIncreasingUlidFlake(t *testing.T) {
-	ulidFlakeID, err := New()
-	assert.Nil(t, err)
+	// a dedicated generator on a manual clock waits for the next millisecond when the randomness is exhausted
+	clock := NewManualClock(time.Date(2024, 6, 6, 6, 6, 6, 0, time.UTC))
+	g, err := NewGenerator(WithClock(clock), WithOverflowPolicy(OverflowWait))
+	require.Nil(t, err)
+	ulidFlakeID, err := g.New()
+	require.Nil(t, err)
 	for i := 0; i < 1000; i++ {
-		newUlidFlake, err := New()
-		assert.Nil(t, err)
+		newUlidFlake, err := g.New()
+		require.Nil(t, err)
 		assert.Greater(t, newUlidFlake.Int(), ulidFlakeID.Int())
 		ulidFlakeID = newUlidFlake
 	}
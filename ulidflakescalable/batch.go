@@ -0,0 +1,45 @@
+package ulidflakescalable
+
+// NewBatch generates n strictly increasing Ulid-Flakes, e.g. for a bulk insert
+func (g *Generator) NewBatch(n int) ([]UlidFlake, error) {
+	if n < 0 {
+		return nil, ErrInvalidConfig
+	}
+	batch := make([]UlidFlake, n)
+	if err := g.Fill(batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// Fill fills dst with strictly increasing Ulid-Flakes.
+// The randomness is incremented by a non-zero entropy as with New, and when it is exhausted
+// the batch rolls into the subsequent milliseconds, which are reserved ahead of the clock
+// up to the maximum drift. Beyond it, the batch waits for the clock instead of failing, whatever the overflow policy.
+func (g *Generator) Fill(dst []UlidFlake) error {
+	return g.generator.Fill(len(dst), func(i int, value int64) {
+		dst[i] = UlidFlake{value: value}
+	})
+}
+
+// FillIDs fills dst with strictly increasing Ulid-Flakes as IDs
+func (g *Generator) FillIDs(dst []ID) error {
+	return g.generator.Fill(len(dst), func(i int, value int64) {
+		dst[i] = ID(value)
+	})
+}
+
+// NewBatch generates n strictly increasing Ulid-Flakes with the default generator
+func NewBatch(n int) ([]UlidFlake, error) {
+	return defaultGenerator.NewBatch(n)
+}
+
+// Fill fills dst with strictly increasing Ulid-Flakes with the default generator
+func Fill(dst []UlidFlake) error {
+	return defaultGenerator.Fill(dst)
+}
+
+// FillIDs fills dst with strictly increasing Ulid-Flakes as IDs with the default generator
+func FillIDs(dst []ID) error {
+	return defaultGenerator.FillIDs(dst)
+}
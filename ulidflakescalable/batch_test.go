@@ -0,0 +1,226 @@
+package ulidflakescalable
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_NewBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		n           int
+		entropySize int
+	}{
+		{
+			name:        "empty batch",
+			n:           0,
+			entropySize: MinEntropySize,
+		},
+		{
+			name:        "single Ulid-Flake",
+			n:           1,
+			entropySize: MinEntropySize,
+		},
+		{
+			name:        "within a millisecond",
+			n:           1000,
+			entropySize: MinEntropySize,
+		},
+		{
+			name:        "rolling into subsequent milliseconds",
+			n:           10000,
+			entropySize: MaxEntropySize,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2024, 6, 6, 6, 6, 6, 0, time.UTC)
+			clock := NewManualClock(start)
+			g, err := NewGenerator(WithClock(clock), WithEntropySize(tt.entropySize), WithOverflowPolicy(OverflowWait))
+			assert.Nil(t, err)
+
+			batch, err := g.NewBatch(tt.n)
+			assert.Nil(t, err)
+			assert.Len(t, batch, tt.n)
+			for i := 1; i < len(batch); i++ {
+				assert.Less(t, batch[i-1].Int(), batch[i].Int())
+			}
+			if tt.n > 0 {
+				assert.Equal(t, start, g.Time(batch[0].ID()))
+			}
+			assert.Equal(t, uint64(tt.n), g.Stats().Generated)
+
+			// Generation continues after the batch, within the reserved milliseconds
+			next, err := g.NewID()
+			assert.Nil(t, err)
+			if tt.n > 0 {
+				assert.Less(t, batch[tt.n-1].ID(), next)
+			}
+			assert.Equal(t, uint64(0), g.Stats().Regressions)
+		})
+	}
+}
+
+func TestGenerator_Fill(t *testing.T) {
+	r := &countingReader{}
+	clock := NewManualClock(time.Date(2024, 6, 6, 6, 6, 6, 0, time.UTC))
+	g, err := NewGenerator(WithClock(clock), WithEntropySource(r), WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+
+	first, err := g.NewID()
+	assert.Nil(t, err)
+	reads := r.reads
+
+	ids := make([]ID, 100)
+	assert.Nil(t, g.FillIDs(ids))
+	assert.Less(t, first, ids[0])
+	for i := 1; i < len(ids); i++ {
+		assert.Less(t, ids[i-1], ids[i])
+	}
+	assert.LessOrEqual(t, r.reads-reads, 1)
+
+	flakes := make([]UlidFlake, 100)
+	assert.Nil(t, g.Fill(flakes))
+	assert.Less(t, ids[len(ids)-1], flakes[0].ID())
+}
+
+func TestGenerator_FillRegression(t *testing.T) {
+	start := time.Date(2024, 6, 6, 6, 6, 6, 0, time.UTC)
+	clock := NewManualClock(start)
+	g, err := NewGenerator(WithClock(clock), WithEntropySize(MaxEntropySize), WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+
+	batch, err := g.NewBatch(100)
+	assert.Nil(t, err)
+	last := batch[len(batch)-1].ID()
+	assert.True(t, g.Time(last).After(start))
+
+	// The clock has not caught up with the reserved milliseconds yet
+	clock.Advance(time.Millisecond)
+	next, err := g.NewID()
+	assert.Nil(t, err)
+	assert.Less(t, last, next)
+
+	// The clock moving backwards behind the batch is still a regression
+	clock.Set(start.Add(-time.Millisecond))
+	_, err = g.NewID()
+	assert.ErrorIs(t, err, ErrInvalidTimestamp)
+	assert.ErrorIs(t, g.FillIDs(make([]ID, 1)), ErrInvalidTimestamp)
+}
+
+func TestGenerator_FillMaxDrift(t *testing.T) {
+	start := time.Date(2024, 6, 6, 6, 6, 6, 0, time.UTC)
+	tests := []struct {
+		name   string
+		policy OverflowPolicy
+	}{
+		{
+			name:   "error",
+			policy: OverflowFail,
+		},
+		{
+			name:   "borrow",
+			policy: OverflowBorrow,
+		},
+		{
+			name:   "wait",
+			policy: OverflowWait,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewManualClock(start)
+			// every Ulid-Flake exhausts the randomness, so each one takes a millisecond
+			g, err := NewGenerator(WithClock(clock), WithEntropySize(MaxEntropySize), WithEntropySource(constantReader(0xFF)),
+				WithOverflowPolicy(tt.policy), WithMaxDrift(5*time.Millisecond))
+			require.Nil(t, err)
+
+			// the batch waits for the clock beyond the maximum drift, whatever the overflow policy
+			for i := 0; i < 5; i++ {
+				batch, err := g.NewBatch(20)
+				require.Nil(t, err)
+				current := clock.Now().Sub(g.EpochTime()).Milliseconds()
+				assert.LessOrEqual(t, batch[len(batch)-1].Timestamp()-current, int64(5))
+				for j := 1; j < len(batch); j++ {
+					assert.Less(t, batch[j-1].Int(), batch[j].Int())
+				}
+			}
+			assert.Greater(t, g.Stats().OverflowWaits, uint64(0))
+		})
+	}
+}
+
+func TestGenerator_FillError(t *testing.T) {
+	g, err := NewGenerator(WithEntropySource(failingReader{}))
+	assert.Nil(t, err)
+	assert.NotNil(t, g.FillIDs(make([]ID, 10)))
+
+	_, err = g.NewBatch(-1)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestNewBatch(t *testing.T) {
+	batch, err := NewBatch(10)
+	assert.Nil(t, err)
+	assert.Len(t, batch, 10)
+
+	assert.Nil(t, Fill(batch))
+	ids := make([]ID, 10)
+	assert.Nil(t, FillIDs(ids))
+	assert.Less(t, batch[9].ID(), ids[0])
+}
+
+func BenchmarkNewBatch(b *testing.B) {
+	const n = 10000
+	g, err := NewGenerator(WithOverflowPolicy(OverflowWait))
+	assert.Nil(b, err)
+	b.Run("NewID", func(b *testing.B) {
+		ids := make([]ID, n)
+		for i := 0; i < b.N; i++ {
+			for j := range ids {
+				if ids[j], err = g.NewID(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("FillIDs", func(b *testing.B) {
+		ids := make([]ID, n)
+		for i := 0; i < b.N; i++ {
+			if err := g.FillIDs(ids); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestGenerator_NewBatchSID(t *testing.T) {
+	g, err := NewGenerator(WithSID(3), WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+
+	batch, err := g.NewBatch(1000)
+	assert.Nil(t, err)
+	for _, u := range batch {
+		assert.Equal(t, int64(3), u.SID())
+	}
+}
+
+func TestGenerator_NewBatchDefaults(t *testing.T) {
+	// large batches roll past the maximum drift with the default options, waiting for the clock
+	for _, n := range []int{3000, 10000, 30000} {
+		g, err := NewGenerator()
+		require.Nil(t, err)
+
+		batch, err := g.NewBatch(n)
+		require.Nil(t, err)
+		require.Len(t, batch, n)
+		for i := 1; i < len(batch); i++ {
+			assert.Less(t, batch[i-1].Int(), batch[i].Int())
+		}
+		current := time.Since(g.EpochTime()).Milliseconds()
+		assert.LessOrEqual(t, batch[n-1].Timestamp()-current, DefaultMaxDrift.Milliseconds())
+	}
+}
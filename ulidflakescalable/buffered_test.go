@@ -0,0 +1,178 @@
+package ulidflakescalable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBufferedGenerator(t *testing.T) {
+	g, err := NewGenerator()
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name      string
+		generator *Generator
+		opts      []BufferedOption
+		wantErr   bool
+	}{
+		{
+			name:      "default options",
+			generator: g,
+		},
+		{
+			name:      "custom options",
+			generator: g,
+			opts:      []BufferedOption{WithBufferSize(16), WithStaleAfter(time.Second)},
+		},
+		{
+			name:    "nil generator",
+			wantErr: true,
+		},
+		{
+			name:      "invalid buffer size",
+			generator: g,
+			opts:      []BufferedOption{WithBufferSize(0)},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid stale threshold",
+			generator: g,
+			opts:      []BufferedOption{WithStaleAfter(0)},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewBufferedGenerator(context.Background(), tt.generator, tt.opts...)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidConfig)
+				assert.Nil(t, b)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Nil(t, b.Close())
+		})
+	}
+}
+
+func TestBufferedGenerator_NewID(t *testing.T) {
+	g, err := NewGenerator(WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+	b, err := NewBufferedGenerator(context.Background(), g, WithBufferSize(64), WithStaleAfter(time.Hour))
+	assert.Nil(t, err)
+	defer b.Close()
+
+	previous, err := b.New()
+	assert.Nil(t, err)
+	for i := 0; i < 1000; i++ {
+		got, err := b.NewID()
+		assert.Nil(t, err)
+		assert.Less(t, previous.ID(), got)
+		previous = got.UlidFlake()
+	}
+}
+
+func TestBufferedGenerator_Stale(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 6, 6, 6, 6, 6, 0, time.UTC))
+	g, err := NewGenerator(WithClock(clock), WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+	b, err := NewBufferedGenerator(context.Background(), g, WithBufferSize(4), WithStaleAfter(10*time.Millisecond))
+	assert.Nil(t, err)
+	defer b.Close()
+
+	assert.Eventually(t, func() bool { return b.Len() == 4 }, time.Second, time.Millisecond)
+
+	clock.Advance(time.Second)
+	got, err := b.NewID()
+	assert.Nil(t, err)
+	assert.LessOrEqual(t, g.Age(got, clock.Now()), 10*time.Millisecond)
+	assert.GreaterOrEqual(t, b.Discarded(), uint64(4))
+}
+
+func TestBufferedGenerator_Error(t *testing.T) {
+	g, err := NewGenerator(WithEntropySource(failingReader{}))
+	assert.Nil(t, err)
+	b, err := NewBufferedGenerator(context.Background(), g)
+	assert.Nil(t, err)
+	defer b.Close()
+
+	_, err = b.NewID()
+	assert.NotNil(t, err)
+	assert.NotErrorIs(t, err, ErrClosed)
+}
+
+func TestBufferedGenerator_Close(t *testing.T) {
+	g, err := NewGenerator()
+	assert.Nil(t, err)
+	b, err := NewBufferedGenerator(context.Background(), g)
+	assert.Nil(t, err)
+
+	assert.Nil(t, b.Close())
+	assert.Nil(t, b.Close())
+	got, err := b.New()
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.Nil(t, got)
+}
+
+func TestBufferedGenerator_Context(t *testing.T) {
+	g, err := NewGenerator()
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	b, err := NewBufferedGenerator(ctx, g)
+	assert.Nil(t, err)
+	defer b.Close()
+
+	cancel()
+	_, err = b.NewID()
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestBufferedGenerator_NewIDContext(t *testing.T) {
+	g, err := NewGenerator(WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+	b, err := NewBufferedGenerator(context.Background(), g, WithStaleAfter(time.Hour))
+	assert.Nil(t, err)
+	defer b.Close()
+
+	got, err := b.NewContext(context.Background())
+	assert.Nil(t, err)
+	next, err := b.NewIDContext(context.Background())
+	assert.Nil(t, err)
+	assert.Less(t, got.ID(), next)
+
+	// a done context stops waiting on the buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 100; i++ {
+		_, err = b.NewIDContext(ctx)
+		if err != nil {
+			break
+		}
+	}
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func BenchmarkBufferedGenerator(b *testing.B) {
+	g, err := NewGenerator(WithOverflowPolicy(OverflowWait))
+	assert.Nil(b, err)
+	b.Run("Generator", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := g.NewID(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("BufferedGenerator", func(b *testing.B) {
+		buffered, err := NewBufferedGenerator(context.Background(), g)
+		assert.Nil(b, err)
+		defer buffered.Close()
+		for i := 0; i < b.N; i++ {
+			if _, err := buffered.NewID(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
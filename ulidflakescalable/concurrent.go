@@ -0,0 +1,52 @@
+package ulidflakescalable
+
+import "github.com/abailinrun/ulid-flake-go/flake"
+
+// ConcurrentGenerator generates Ulid-Flakes without a mutex, by atomically swapping the last
+// generated Ulid-Flake, which packs the timestamp and the randomness of the monotonic state.
+// It scales with the number of goroutines generating concurrently, and still guarantees that the
+// Ulid-Flakes are strictly increasing in the order they are generated within the process.
+// Its configuration is fixed at creation, and its entropy source must be safe for concurrent use,
+// as all the sources of this package are.
+type ConcurrentGenerator struct {
+	generator *flake.ConcurrentGenerator
+}
+
+// NewConcurrentGenerator creates a new ConcurrentGenerator configured with functional options
+func NewConcurrentGenerator(opts ...Option) (*ConcurrentGenerator, error) {
+	cfg, err := newConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	generator, err := flake.NewConcurrentGenerator(cfg.Config)
+	if err != nil {
+		return nil, err
+	}
+	return &ConcurrentGenerator{generator: generator}, nil
+}
+
+// New generates a new Ulid-Flake with the generator's entropy size and sid
+func (g *ConcurrentGenerator) New() (*UlidFlake, error) {
+	id, err := g.NewID()
+	if err != nil {
+		return nil, err
+	}
+	return NewUlidFlake(int64(id))
+}
+
+// NewID generates a new Ulid-Flake as an ID.
+// It follows the overflow and regression policies of the generator as Generator.NewID does.
+func (g *ConcurrentGenerator) NewID() (ID, error) {
+	id, err := g.generator.NewID()
+	return ID(id), err
+}
+
+// Layout returns the bit layout of the generator
+func (g *ConcurrentGenerator) Layout() flake.Layout {
+	return g.generator.Config().Layout
+}
+
+// Stats returns a snapshot of the generator's counters
+func (g *ConcurrentGenerator) Stats() Stats {
+	return g.generator.Stats()
+}
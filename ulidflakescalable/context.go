@@ -0,0 +1,28 @@
+package ulidflakescalable
+
+import "context"
+
+// NewContext generates a new Ulid-Flake, waiting for the recoverable conditions to clear
+// until the context is done
+func (g *Generator) NewContext(ctx context.Context) (*UlidFlake, error) {
+	id, err := g.NewIDContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewUlidFlake(int64(id))
+}
+
+// NewIDContext generates a new Ulid-Flake as an ID. When the randomness overflows within a millisecond
+// or the clock moved backwards, it waits for the next millisecond or for the clock to catch up instead
+// of failing, until the context is done. The error is then wrapped together with the context's error.
+// It returns without waiting if the wait would exceed the context's deadline, as measured by the generator's clock.
+func (g *Generator) NewIDContext(ctx context.Context) (ID, error) {
+	id, err := g.generator.NewIDContext(ctx)
+	return ID(id), err
+}
+
+// NewContext generates a new Ulid-Flake with the default generator, waiting for the recoverable conditions
+// to clear until the context is done
+func NewContext(ctx context.Context) (*UlidFlake, error) {
+	return defaultGenerator.NewContext(ctx)
+}
@@ -0,0 +1,133 @@
+package ulidflakescalable
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_NewContext(t *testing.T) {
+	// the clock starts at the wall time the contexts' deadlines are set against
+	start := time.Now().UTC().Truncate(time.Millisecond)
+	tests := []struct {
+		name      string
+		opts      []Option
+		setup     func(g *Generator, clock *ManualClock)
+		timeout   time.Duration
+		wantClock time.Time
+		wantErrs  []error
+	}{
+		{
+			name:      "no recoverable condition",
+			setup:     func(g *Generator, clock *ManualClock) {},
+			wantClock: start,
+		},
+		{
+			name: "wait for the next millisecond on overflow",
+			// a saturated entropy gives the first Ulid-Flake the maximum randomness, so that the next one overflows
+			opts:      []Option{WithEntropySource(constantReader(0xFF))},
+			setup:     func(g *Generator, clock *ManualClock) {},
+			wantClock: start.Add(time.Millisecond),
+		},
+		{
+			name: "wait for the clock to catch up after a regression",
+			setup: func(g *Generator, clock *ManualClock) {
+				clock.Rewind(5 * time.Millisecond)
+			},
+			wantClock: start,
+		},
+		{
+			name: "regression longer than the deadline",
+			setup: func(g *Generator, clock *ManualClock) {
+				clock.Advance(time.Hour)
+				_, err := g.New()
+				require.Nil(t, err)
+				clock.Rewind(time.Hour)
+			},
+			timeout:   time.Second,
+			wantClock: start,
+			wantErrs:  []error{ErrInvalidTimestamp, context.DeadlineExceeded},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewManualClock(start)
+			g, err := NewGenerator(append([]Option{WithClock(clock), WithEntropySource(NewSeededSource(42))}, tt.opts...)...)
+			require.Nil(t, err)
+
+			first, err := g.New()
+			require.Nil(t, err)
+			tt.setup(g, clock)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			got, err := g.NewContext(ctx)
+			assert.Equal(t, tt.wantClock, clock.Now())
+			if tt.wantErrs != nil {
+				for _, wantErr := range tt.wantErrs {
+					assert.ErrorIs(t, err, wantErr)
+				}
+				assert.Nil(t, got)
+				return
+			}
+			require.Nil(t, err)
+			assert.Greater(t, got.Int(), first.Int())
+		})
+	}
+}
+
+func TestGenerator_NewIDContextCanceledWhileWaiting(t *testing.T) {
+	// a high-water mark an hour ahead of the clock is a regression the generator waits out
+	store := NewFileStateStore(filepath.Join(t.TempDir(), "state"))
+	require.Nil(t, store.Save(time.Now().Add(time.Hour)))
+	g, err := NewGenerator(WithStateStore(store))
+	require.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	got, err := g.NewIDContext(ctx)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.ErrorIs(t, err, ErrInvalidTimestamp)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, Zero, got)
+}
+
+func TestGenerator_NewIDContextCanceled(t *testing.T) {
+	g, err := NewGenerator()
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got, err := g.NewIDContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, Zero, got)
+}
+
+func TestGenerator_NewIDContextUnrecoverable(t *testing.T) {
+	g, err := NewGenerator(WithEntropySource(failingReader{}))
+	assert.Nil(t, err)
+
+	_, err = g.NewIDContext(context.Background())
+	assert.NotNil(t, err)
+	assert.NotErrorIs(t, err, context.DeadlineExceeded)
+
+	g, err = NewGenerator(WithEpochTime(time.Now().Add(time.Hour)))
+	assert.Nil(t, err)
+	_, err = g.NewIDContext(context.Background())
+	assert.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestNewContext(t *testing.T) {
+	got, err := NewContext(context.Background())
+	assert.Nil(t, err)
+	assert.NotNil(t, got)
+}
@@ -0,0 +1,84 @@
+package ulidflakescalable
+
+import (
+	"errors"
+	mathrand "math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingReader counts the reads made to the underlying reader
+type countingReader struct {
+	reads int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.reads++
+	for i := range p {
+		p[i] = byte(i)
+	}
+	return len(p), nil
+}
+
+// constantReader fills every read with the same byte, so that the randomness of a new millisecond
+// starts far below the maximum and increments by the byte
+type constantReader byte
+
+func (r constantReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+// runtimeReader fills every read from the runtime's per-thread generator, without taking a lock
+type runtimeReader struct{}
+
+func (runtimeReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(mathrand.Uint32())
+	}
+	return len(p), nil
+}
+
+// failingReader always fails
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("entropy exhausted")
+}
+
+func TestGenerator_EntropySource(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC)
+	generate := func() []int64 {
+		g, err := NewGenerator(WithClock(NewManualClock(start)), WithEntropySource(NewSeededSource(7)))
+		assert.Nil(t, err)
+		var ids []int64
+		for i := 0; i < 10; i++ {
+			id, err := g.New()
+			assert.Nil(t, err)
+			ids = append(ids, id.Int())
+		}
+		return ids
+	}
+	assert.Equal(t, generate(), generate())
+
+	r := &countingReader{}
+	g, err := NewGenerator(WithEntropySource(NewBufferedSource(r, DefaultBufferSize)))
+	assert.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		_, err := g.New()
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 1, r.reads)
+
+	g, err = NewGenerator(WithEntropySource(failingReader{}))
+	assert.Nil(t, err)
+	_, err = g.New()
+	assert.NotNil(t, err)
+
+	_, err = NewGenerator(WithEntropySource(nil))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
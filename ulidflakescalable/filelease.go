@@ -0,0 +1,89 @@
+package ulidflakescalable
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// FileLease holds a scalability ID claimed through a lock file, so that processes on one host
+// sharing the directory get distinct scalability IDs without central coordination.
+// The lock is released by Close, or by the operating system when the process exits or crashes.
+type FileLease struct {
+	mutex  sync.Mutex
+	sid    int64
+	file   *os.File
+	closed atomic.Bool
+}
+
+// AcquireFileLease claims the lowest scalability ID between 0 and maxSID whose lock file in dir
+// is not locked by another holder, creating dir and the lock files as needed.
+// It returns ErrSIDUnavailable if all of them are leased.
+func AcquireFileLease(dir string, maxSID int64) (*FileLease, error) {
+	if maxSID < MinScalability || maxSID > 1<<MaxSIDBits-1 {
+		return nil, ErrInvalidConfig
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	for sid := int64(MinScalability); sid <= maxSID; sid++ {
+		file, err := os.OpenFile(leasePath(dir, sid), os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		if err := lockFile(file); err != nil {
+			file.Close()
+			if errors.Is(err, errLocked) {
+				continue
+			}
+			return nil, err
+		}
+		// The holder's process ID is informational only, the lock is what counts
+		if err := file.Truncate(0); err == nil {
+			file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+		}
+		return &FileLease{sid: sid, file: file}, nil
+	}
+	return nil, fmt.Errorf("%w: all %d sids in %s are leased", ErrSIDUnavailable, maxSID+1, dir)
+}
+
+// SID returns the leased scalability ID
+func (l *FileLease) SID() int64 {
+	return l.sid
+}
+
+// Err returns ErrLeaseLost once the lease is closed, or nil while it is held
+func (l *FileLease) Err() error {
+	if l.closed.Load() {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Close releases the lease. The generators holding it stop issuing Ulid-Flakes.
+// The lock file is kept, as removing it could let two processes lock different files of the same path.
+func (l *FileLease) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.closed.Store(true)
+	if l.file == nil {
+		return nil
+	}
+	err := unlockFile(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	return err
+}
+
+// leasePath returns the path of the lock file of the scalability ID
+func leasePath(dir string, sid int64) string {
+	return filepath.Join(dir, fmt.Sprintf("sid-%d.lock", sid))
+}
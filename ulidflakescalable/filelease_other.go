@@ -0,0 +1,21 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package ulidflakescalable
+
+import (
+	"errors"
+	"os"
+)
+
+// errLocked is returned by lockFile when another holder has locked the file
+var errLocked = errors.New("file locked")
+
+// lockFile is not supported without flock
+func lockFile(file *os.File) error {
+	return errors.ErrUnsupported
+}
+
+// unlockFile is not supported without flock
+func unlockFile(file *os.File) error {
+	return errors.ErrUnsupported
+}
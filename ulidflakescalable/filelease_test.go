@@ -0,0 +1,212 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package ulidflakescalable
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const leaseHelperEnv = "ULIDFLAKE_LEASE_HELPER_DIR"
+
+// TestFileLeaseHelperProcess is run as a child process by the tests below. It leases a scalability ID,
+// prints it and holds the lease until its standard input is closed.
+func TestFileLeaseHelperProcess(t *testing.T) {
+	dir := os.Getenv(leaseHelperEnv)
+	if dir == "" {
+		t.Skip("helper process")
+	}
+	lease, err := AcquireFileLease(dir, 7)
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+	fmt.Println(lease.SID())
+	io.Copy(io.Discard, os.Stdin)
+	lease.Close()
+	os.Exit(0)
+}
+
+// leaseHolder is a child process holding a lease
+type leaseHolder struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	sid   int64
+	err   string
+}
+
+// startLeaseHolder starts a child process leasing a scalability ID from dir
+func startLeaseHolder(t *testing.T, dir string) *leaseHolder {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFileLeaseHelperProcess$")
+	cmd.Env = append(os.Environ(), leaseHelperEnv+"="+dir)
+	stdin, err := cmd.StdinPipe()
+	assert.Nil(t, err)
+	stdout, err := cmd.StdoutPipe()
+	assert.Nil(t, err)
+	assert.Nil(t, cmd.Start())
+	t.Cleanup(func() {
+		stdin.Close()
+		cmd.Wait()
+	})
+
+	h := &leaseHolder{cmd: cmd, stdin: stdin, sid: -1}
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	assert.Nil(t, err)
+	line = strings.TrimSpace(line)
+	if sid, err := strconv.ParseInt(line, 10, 64); err == nil {
+		h.sid = sid
+	} else {
+		h.err = line
+	}
+	return h
+}
+
+func TestAcquireFileLease(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := AcquireFileLease(dir, MaxScalability)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), first.SID())
+
+	second, err := AcquireFileLease(dir, MaxScalability)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), second.SID())
+
+	assert.Nil(t, first.Close())
+	assert.Nil(t, first.Close())
+
+	third, err := AcquireFileLease(dir, MaxScalability)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), third.SID())
+
+	assert.Nil(t, second.Close())
+	assert.Nil(t, third.Close())
+
+	data, err := os.ReadFile(leasePath(dir, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid())+"\n", string(data))
+
+	_, err = AcquireFileLease(dir, -1)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = AcquireFileLease(dir, 1<<MaxSIDBits)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestWithSIDLease_FileLease(t *testing.T) {
+	lease, err := AcquireFileLease(t.TempDir(), MaxScalability)
+	assert.Nil(t, err)
+	assert.Nil(t, lease.Err())
+
+	g, err := NewGenerator(WithSIDLease(lease))
+	assert.Nil(t, err)
+	cg, err := NewConcurrentGenerator(WithSIDLease(lease))
+	assert.Nil(t, err)
+	id, err := g.NewID()
+	assert.Nil(t, err)
+	assert.Equal(t, lease.SID(), id.SID())
+	_, err = cg.NewID()
+	assert.Nil(t, err)
+
+	assert.Nil(t, lease.Close())
+	assert.ErrorIs(t, lease.Err(), ErrLeaseLost)
+	_, err = g.NewID()
+	assert.ErrorIs(t, err, ErrLeaseLost)
+	_, err = cg.NewID()
+	assert.ErrorIs(t, err, ErrLeaseLost)
+	_, err = g.NewBatch(10)
+	assert.ErrorIs(t, err, ErrLeaseLost)
+}
+
+func TestAcquireFileLease_Exhausted(t *testing.T) {
+	dir := t.TempDir()
+	var leases []*FileLease
+	for i := 0; i < 4; i++ {
+		lease, err := AcquireFileLease(dir, 3)
+		assert.Nil(t, err)
+		leases = append(leases, lease)
+	}
+
+	_, err := AcquireFileLease(dir, 3)
+	assert.ErrorIs(t, err, ErrSIDUnavailable)
+
+	for _, lease := range leases {
+		assert.Nil(t, lease.Close())
+	}
+}
+
+func TestAcquireFileLease_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	leases := make([]*FileLease, 32)
+	for i := range leases {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			lease, err := AcquireFileLease(dir, MaxScalability)
+			assert.Nil(t, err)
+			leases[i] = lease
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[int64]bool{}
+	for _, lease := range leases {
+		assert.False(t, seen[lease.SID()])
+		seen[lease.SID()] = true
+		assert.Nil(t, lease.Close())
+	}
+}
+
+func TestAcquireFileLease_Processes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spawns child processes")
+	}
+	dir := t.TempDir()
+
+	holders := make([]*leaseHolder, 8)
+	var wg sync.WaitGroup
+	for i := range holders {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			holders[i] = startLeaseHolder(t, dir)
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[int64]bool{}
+	for _, h := range holders {
+		assert.Empty(t, h.err)
+		assert.False(t, seen[h.sid], "sid %d leased twice", h.sid)
+		seen[h.sid] = true
+	}
+	assert.Len(t, seen, 8)
+
+	extra := startLeaseHolder(t, dir)
+	assert.Contains(t, extra.err, ErrSIDUnavailable.Error())
+
+	// A crashed holder releases its lease
+	crashed := holders[3]
+	assert.Nil(t, crashed.cmd.Process.Kill())
+	crashed.cmd.Wait()
+	replacement := startLeaseHolder(t, dir)
+	assert.Empty(t, replacement.err)
+	assert.Equal(t, crashed.sid, replacement.sid)
+
+	// A closed lease is released too
+	closed := holders[5]
+	closed.stdin.Close()
+	assert.Nil(t, closed.cmd.Wait())
+	replacement = startLeaseHolder(t, dir)
+	assert.Equal(t, closed.sid, replacement.sid)
+}
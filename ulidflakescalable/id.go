@@ -0,0 +1,144 @@
+package ulidflakescalable
+
+import (
+	"cmp"
+	"io"
+	"strconv"
+)
+
+const (
+	hexLen = len("0x") + 16 // Maximal length of the hexadecimal string representation
+	binLen = len("0b") + 64 // Maximal length of the binary string representation
+)
+
+// ID is a Ulid-Flake held by value. Unlike *UlidFlake it can be compared with ==,
+// used as a map key and stored compactly in slices without heap allocations.
+type ID int64
+
+// Zero is the zero value of ID, which is never generated
+const Zero ID = 0
+
+// NewID generates a new Ulid-Flake as an ID with the default generator
+func NewID() (ID, error) {
+	return defaultGenerator.NewID()
+}
+
+// ParseID parses a Ulid-Flake string into an ID
+func ParseID(ulidFlakeString string) (ID, error) {
+	return parseID(ulidFlakeString, false)
+}
+
+// ParseIDStrict parses a Ulid-Flake string into an ID, accepting only the canonical uppercase Base32 characters
+func ParseIDStrict(ulidFlakeString string) (ID, error) {
+	return parseID(ulidFlakeString, true)
+}
+
+// Compare returns -1, 0 or +1 depending on whether a sorts before, the same as or after b.
+// It can be passed to slices.SortFunc.
+func Compare(a, b ID) int {
+	return cmp.Compare(a, b)
+}
+
+// ID returns the value-type representation of the UlidFlake
+func (u *UlidFlake) ID() ID {
+	return ID(u.value)
+}
+
+// UlidFlake returns the pointer representation of the ID
+func (id ID) UlidFlake() *UlidFlake {
+	return &UlidFlake{value: int64(id)}
+}
+
+// String returns the Base32 string representation
+func (id ID) String() string {
+	var buf [UlidFlakeLen]byte
+	return string(id.AppendString(buf[:0]))
+}
+
+// Int returns the integer representation
+func (id ID) Int() int64 {
+	return int64(id)
+}
+
+// Hex returns the hexadecimal string representation
+func (id ID) Hex() string {
+	var buf [hexLen]byte
+	return string(id.AppendHex(buf[:0]))
+}
+
+// Bin returns the binary string representation
+func (id ID) Bin() string {
+	var buf [binLen]byte
+	return string(id.AppendBin(buf[:0]))
+}
+
+// AppendString appends the Base32 string representation to dst and returns the extended buffer
+func (id ID) AppendString(dst []byte) []byte {
+	return appendBase32(dst, int64(id), UlidFlakeLen)
+}
+
+// AppendHex appends the hexadecimal string representation to dst and returns the extended buffer
+func (id ID) AppendHex(dst []byte) []byte {
+	dst = append(dst, "0x"...)
+	n := len(dst)
+	dst = strconv.AppendInt(dst, int64(id), 16)
+	for i := n; i < len(dst); i++ {
+		if 'a' <= dst[i] && dst[i] <= 'f' {
+			dst[i] -= 'a' - 'A'
+		}
+	}
+	return dst
+}
+
+// AppendBin appends the binary string representation to dst and returns the extended buffer
+func (id ID) AppendBin(dst []byte) []byte {
+	dst = append(dst, "0b"...)
+	return strconv.AppendInt(dst, int64(id), 2)
+}
+
+// MarshalTo writes the Base32 string representation into dst and returns the number of bytes written.
+// It returns io.ErrShortBuffer if dst is shorter than UlidFlakeLen.
+func (id ID) MarshalTo(dst []byte) (int, error) {
+	if len(dst) < UlidFlakeLen {
+		return 0, io.ErrShortBuffer
+	}
+	id.AppendString(dst[:0])
+	return UlidFlakeLen, nil
+}
+
+// Timestamp returns the timestamp component
+func (id ID) Timestamp() int64 {
+	return layout.Timestamp(int64(id))
+}
+
+// Randomness returns the randomness component for scalable version, under the default 5-bit scalability ID layout.
+// Decode the Ulid-Flakes of a generator with WithSIDBits with Generator.Randomness instead.
+func (id ID) Randomness() int64 {
+	return layout.Randomness(int64(id))
+}
+
+// SID returns the scalability component, under the default 5-bit scalability ID layout.
+// Decode the Ulid-Flakes of a generator with WithSIDBits with Generator.SID instead.
+func (id ID) SID() int64 {
+	return layout.Node(int64(id))
+}
+
+// Compare returns -1, 0 or +1 depending on whether the ID sorts before, the same as or after other
+func (id ID) Compare(other ID) int {
+	return Compare(id, other)
+}
+
+// Less reports whether the ID sorts before other
+func (id ID) Less(other ID) bool {
+	return id < other
+}
+
+// Equal reports whether the ID is equal to other
+func (id ID) Equal(other ID) bool {
+	return id == other
+}
+
+// IsZero reports whether the ID is the zero value
+func (id ID) IsZero() bool {
+	return id == Zero
+}
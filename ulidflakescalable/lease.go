@@ -0,0 +1,297 @@
+package ulidflakescalable
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultLeaseTTL = 10 * time.Second // Default time to live of a scalability ID lease
+
+const leaseCheckInterval = 100 * time.Millisecond // Longest wall time between checks of the lease clock for a due renewal
+
+// ErrLeaseLost is returned when a scalability ID lease expired or was taken over, and by the generators
+// holding it, which stop issuing Ulid-Flakes rather than risking the same scalability ID on two nodes
+var ErrLeaseLost = errors.New("sid lease lost")
+
+// LeaseStore coordinates the scalability IDs of generators across hosts with leases that expire
+// unless renewed, so that the scalability ID of a crashed node becomes free again
+type LeaseStore interface {
+	// Acquire leases a free scalability ID between 0 and maxSID to the owner for the TTL,
+	// or returns ErrSIDUnavailable if all of them are leased
+	Acquire(ctx context.Context, owner string, maxSID int64, ttl time.Duration) (int64, error)
+
+	// Renew extends the lease of the owner by the TTL, or returns ErrLeaseLost if it no longer holds it
+	Renew(ctx context.Context, owner string, sid int64, ttl time.Duration) error
+
+	// Release ends the lease of the owner, if it still holds it
+	Release(ctx context.Context, owner string, sid int64) error
+}
+
+// LeaseOption defines the type for functional options of AcquireSIDLease
+type LeaseOption func(*leaseConfig) error
+
+type leaseConfig struct {
+	owner  string
+	maxSID int64
+	ttl    time.Duration
+	clock  Clock
+}
+
+// Lease is a scalability ID held for a generator, such as a SIDLease or a FileLease.
+// The generators holding it stop issuing Ulid-Flakes once Err returns an error.
+type Lease interface {
+	// SID returns the leased scalability ID
+	SID() int64
+
+	// Err returns ErrLeaseLost once the lease is lost or closed, or nil while it is held
+	Err() error
+}
+
+// LeaseStoreOption defines the type for functional options of the lease stores
+type LeaseStoreOption func(*leaseStoreConfig) error
+
+type leaseStoreConfig struct {
+	clock       Clock
+	placeholder func(n int) string
+	keyPrefix   string
+	dial        func(ctx context.Context) (net.Conn, error)
+}
+
+// newLeaseStoreConfig creates a lease store configuration from the default values and the given options
+func newLeaseStoreConfig(opts ...LeaseStoreOption) (*leaseStoreConfig, error) {
+	cfg := &leaseStoreConfig{
+		clock:       SystemClock{},
+		placeholder: func(int) string { return "?" },
+		keyPrefix:   DefaultLeaseKeyPrefix,
+	}
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// SIDLease holds a scalability ID leased from a LeaseStore, and renews it in the background
+// every third of the TTL on its clock. The lease counts as lost from the TTL after the start
+// of the last successful renewal, even if the store has not expired it yet.
+type SIDLease struct {
+	store    LeaseStore
+	owner    string
+	sid      int64
+	ttl      time.Duration
+	clock    Clock
+	deadline atomic.Int64 // Unix nanoseconds until which the lease is held, 0 once lost or released
+	cancel   context.CancelFunc
+	done     chan struct{}
+	once     sync.Once
+}
+
+// AcquireSIDLease leases a scalability ID from the store and starts renewing it
+func AcquireSIDLease(ctx context.Context, store LeaseStore, opts ...LeaseOption) (*SIDLease, error) {
+	if store == nil {
+		return nil, ErrInvalidConfig
+	}
+	cfg := &leaseConfig{
+		maxSID: MaxScalability,
+		ttl:    DefaultLeaseTTL,
+		clock:  SystemClock{},
+	}
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.owner == "" {
+		cfg.owner = newLeaseOwner()
+	}
+
+	start := cfg.clock.Now()
+	sid, err := store.Acquire(ctx, cfg.owner, cfg.maxSID, cfg.ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	renewCtx, cancel := context.WithCancel(context.Background())
+	l := &SIDLease{
+		store:  store,
+		owner:  cfg.owner,
+		sid:    sid,
+		ttl:    cfg.ttl,
+		clock:  cfg.clock,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	l.deadline.Store(start.Add(cfg.ttl).UnixNano())
+	go l.renew(renewCtx, start)
+	return l, nil
+}
+
+// newLeaseOwner returns an owner identifying this process uniquely
+func newLeaseOwner() string {
+	host, _ := hostname()
+	var suffix [8]byte
+	rand.Read(suffix[:])
+	return fmt.Sprintf("%s/%d/%s", host, os.Getpid(), hex.EncodeToString(suffix[:]))
+}
+
+// renew renews the lease every third of the TTL on the lease's clock until it is lost or closed.
+// The clock is checked every 100ms of wall time, or every third of the TTL if that is shorter,
+// so that renewals follow a ManualClock as well as the system clock.
+func (l *SIDLease) renew(ctx context.Context, start time.Time) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(min(l.ttl/3, leaseCheckInterval))
+	defer ticker.Stop()
+	due := start.Add(l.ttl / 3)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := l.clock.Now()
+		if now.Before(due) {
+			continue
+		}
+		if errors.Is(l.renewAt(ctx, now), ErrLeaseLost) {
+			return
+		}
+		due = now.Add(l.ttl / 3)
+	}
+}
+
+// renewAt renews the lease in the store at the given time of the lease's clock, holding it
+// for the TTL from then on. A lease past its deadline is lost and never renewed.
+func (l *SIDLease) renewAt(ctx context.Context, now time.Time) error {
+	deadline := l.deadline.Load()
+	if now.UnixNano() >= deadline {
+		l.deadline.Store(0)
+		return ErrLeaseLost
+	}
+
+	renewCtx, cancel := context.WithTimeout(ctx, l.ttl/3)
+	defer cancel()
+	err := l.store.Renew(renewCtx, l.owner, l.sid, l.ttl)
+	switch {
+	case err == nil:
+		// Close may have ended the lease during the renewal
+		l.deadline.CompareAndSwap(deadline, now.Add(l.ttl).UnixNano())
+	case errors.Is(err, ErrLeaseLost):
+		l.deadline.Store(0)
+	}
+	return err
+}
+
+// SID returns the leased scalability ID
+func (l *SIDLease) SID() int64 {
+	return l.sid
+}
+
+// Owner returns the owner the lease is held by in the store
+func (l *SIDLease) Owner() string {
+	return l.owner
+}
+
+// Err returns ErrLeaseLost once the lease is lost or closed, or nil while it is held
+func (l *SIDLease) Err() error {
+	if l.clock.Now().UnixNano() >= l.deadline.Load() {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Close stops renewing and releases the lease. The generators holding it stop issuing Ulid-Flakes.
+func (l *SIDLease) Close() error {
+	var err error
+	l.once.Do(func() {
+		held := l.Err() == nil
+		l.deadline.Store(0)
+		l.cancel()
+		<-l.done
+		if held {
+			ctx, cancel := context.WithTimeout(context.Background(), l.ttl)
+			defer cancel()
+			err = l.store.Release(ctx, l.owner, l.sid)
+		}
+	})
+	return err
+}
+
+// WithLeaseOwner sets the owner the lease is held by, instead of one derived from the hostname and process
+func WithLeaseOwner(owner string) LeaseOption {
+	return func(cfg *leaseConfig) error {
+		if owner == "" {
+			return ErrInvalidConfig
+		}
+		cfg.owner = owner
+		return nil
+	}
+}
+
+// WithLeaseMaxSID sets the maximal scalability ID to lease, e.g. for a layout set with WithSIDBits
+func WithLeaseMaxSID(maxSID int64) LeaseOption {
+	return func(cfg *leaseConfig) error {
+		if maxSID < MinScalability || maxSID > 1<<MaxSIDBits-1 {
+			return ErrInvalidConfig
+		}
+		cfg.maxSID = maxSID
+		return nil
+	}
+}
+
+// WithLeaseTTL sets how long the lease is held without renewal
+func WithLeaseTTL(ttl time.Duration) LeaseOption {
+	return func(cfg *leaseConfig) error {
+		if ttl < 3*time.Millisecond {
+			return ErrInvalidConfig
+		}
+		cfg.ttl = ttl
+		return nil
+	}
+}
+
+// WithLeaseClock sets the clock the renewals of the lease are timed by and its expiry is checked against
+func WithLeaseClock(clock Clock) LeaseOption {
+	return func(cfg *leaseConfig) error {
+		if clock == nil {
+			return ErrInvalidConfig
+		}
+		cfg.clock = clock
+		return nil
+	}
+}
+
+// WithSIDLease sets the scalability ID of a lease, such as a SIDLease or a FileLease. The generator
+// stops issuing Ulid-Flakes with ErrLeaseLost once the lease is lost or closed.
+func WithSIDLease(lease Lease) Option {
+	return func(cfg *config) error {
+		if lease == nil {
+			return ErrInvalidConfig
+		}
+		cfg.Node = lease.SID()
+		cfg.sidStrategy = nil
+		cfg.Lease = lease
+		return nil
+	}
+}
+
+// WithStoreClock sets the clock the memory and SQL lease stores expire the leases against
+func WithStoreClock(clock Clock) LeaseStoreOption {
+	return func(cfg *leaseStoreConfig) error {
+		if clock == nil {
+			return ErrInvalidConfig
+		}
+		cfg.clock = clock
+		return nil
+	}
+}
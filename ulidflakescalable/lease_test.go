@@ -0,0 +1,220 @@
+package ulidflakescalable
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyLeaseStore fails the renewals with err while failing is set
+type flakyLeaseStore struct {
+	LeaseStore
+	failing atomic.Bool
+	err     error
+	renews  atomic.Int64
+}
+
+func (s *flakyLeaseStore) Renew(ctx context.Context, owner string, sid int64, ttl time.Duration) error {
+	s.renews.Add(1)
+	if s.failing.Load() {
+		return s.err
+	}
+	return s.LeaseStore.Renew(ctx, owner, sid, ttl)
+}
+
+func newTestMemoryLeaseStore(t *testing.T, opts ...LeaseStoreOption) *MemoryLeaseStore {
+	t.Helper()
+	store, err := NewMemoryLeaseStore(opts...)
+	assert.Nil(t, err)
+	return store
+}
+
+func TestAcquireSIDLease(t *testing.T) {
+	ctx := context.Background()
+	store := newTestMemoryLeaseStore(t)
+
+	first, err := AcquireSIDLease(ctx, store)
+	assert.Nil(t, err)
+	defer first.Close()
+	assert.Equal(t, int64(0), first.SID())
+	assert.NotEmpty(t, first.Owner())
+	assert.Nil(t, first.Err())
+
+	second, err := AcquireSIDLease(ctx, store, WithLeaseOwner("node-b"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), second.SID())
+	assert.Equal(t, "node-b", second.Owner())
+
+	assert.Nil(t, second.Close())
+	assert.ErrorIs(t, second.Err(), ErrLeaseLost)
+	assert.Nil(t, second.Close())
+
+	third, err := AcquireSIDLease(ctx, store)
+	assert.Nil(t, err)
+	defer third.Close()
+	assert.Equal(t, int64(1), third.SID())
+
+	_, err = AcquireSIDLease(ctx, nil)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = AcquireSIDLease(ctx, store, WithLeaseTTL(time.Millisecond))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = AcquireSIDLease(ctx, store, WithLeaseMaxSID(1<<MaxSIDBits))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = AcquireSIDLease(ctx, store, WithLeaseOwner(""))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestAcquireSIDLease_Exhausted(t *testing.T) {
+	ctx := context.Background()
+	store := newTestMemoryLeaseStore(t)
+	for i := 0; i < 4; i++ {
+		lease, err := AcquireSIDLease(ctx, store, WithLeaseMaxSID(3))
+		assert.Nil(t, err)
+		defer lease.Close()
+	}
+
+	_, err := AcquireSIDLease(ctx, store, WithLeaseMaxSID(3))
+	assert.ErrorIs(t, err, ErrSIDUnavailable)
+}
+
+// waitRenewed waits until the lease was renewed at the current time of the clock
+func waitRenewed(t *testing.T, lease *SIDLease, clock *ManualClock) {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		return lease.deadline.Load() == clock.Now().Add(lease.ttl).UnixNano()
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestSIDLease_Renew(t *testing.T) {
+	ctx := context.Background()
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC))
+	store := &flakyLeaseStore{
+		LeaseStore: newTestMemoryLeaseStore(t, WithStoreClock(clock)),
+		err:        errors.New("connection refused"),
+	}
+	lease, err := AcquireSIDLease(ctx, store, WithLeaseTTL(30*time.Second), WithLeaseClock(clock))
+	assert.Nil(t, err)
+	defer lease.Close()
+
+	// The renewals are timed by the lease's clock, which has not moved yet
+	time.Sleep(3 * leaseCheckInterval)
+	assert.Equal(t, int64(0), store.renews.Load())
+
+	for i := 1; i <= 3; i++ {
+		clock.Advance(10 * time.Second)
+		waitRenewed(t, lease, clock)
+		assert.Equal(t, int64(i), store.renews.Load())
+	}
+	// Held past the TTL of the acquisition
+	assert.Nil(t, lease.Err())
+
+	store.failing.Store(true)
+	clock.Advance(10 * time.Second)
+	assert.Eventually(t, func() bool { return store.renews.Load() == 4 }, 5*time.Second, time.Millisecond)
+	assert.Nil(t, lease.Err())
+	clock.Advance(20 * time.Second)
+	assert.ErrorIs(t, lease.Err(), ErrLeaseLost)
+
+	// A lost lease is not renewed once the store recovers
+	store.failing.Store(false)
+	clock.Advance(10 * time.Second)
+	assert.Eventually(t, func() bool {
+		select {
+		case <-lease.done:
+			return true
+		default:
+			return false
+		}
+	}, 5*time.Second, time.Millisecond)
+	assert.ErrorIs(t, lease.Err(), ErrLeaseLost)
+	assert.Equal(t, int64(4), store.renews.Load())
+}
+
+func TestSIDLease_TakenOver(t *testing.T) {
+	ctx := context.Background()
+	store := &flakyLeaseStore{LeaseStore: newTestMemoryLeaseStore(t), err: ErrLeaseLost}
+	lease, err := AcquireSIDLease(ctx, store, WithLeaseTTL(time.Second))
+	assert.Nil(t, err)
+	defer lease.Close()
+
+	store.failing.Store(true)
+	assert.Eventually(t, func() bool { return lease.Err() != nil }, time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, lease.Err(), ErrLeaseLost)
+}
+
+func TestWithSIDLease(t *testing.T) {
+	ctx := context.Background()
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC))
+	store := newTestMemoryLeaseStore(t, WithStoreClock(clock))
+	_, err := store.Acquire(ctx, "other", MaxScalability, time.Minute)
+	assert.Nil(t, err)
+
+	lease, err := AcquireSIDLease(ctx, store, WithLeaseClock(clock))
+	assert.Nil(t, err)
+	defer lease.Close()
+	assert.Equal(t, int64(1), lease.SID())
+
+	g, err := NewGenerator(WithClock(clock), WithSIDLease(lease), WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+	cg, err := NewConcurrentGenerator(WithClock(clock), WithSIDLease(lease), WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+
+	id, err := g.NewID()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), id.SID())
+	id, err = cg.NewID()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), id.SID())
+	_, err = g.NewBatch(10)
+	assert.Nil(t, err)
+
+	// The lease is renewed on the clock the generators read
+	clock.Advance(DefaultLeaseTTL / 2)
+	waitRenewed(t, lease, clock)
+	clock.Advance(DefaultLeaseTTL / 2)
+	_, err = g.NewID()
+	assert.Nil(t, err)
+
+	clock.Advance(DefaultLeaseTTL)
+	_, err = g.NewID()
+	assert.ErrorIs(t, err, ErrLeaseLost)
+	_, err = cg.NewID()
+	assert.ErrorIs(t, err, ErrLeaseLost)
+	_, err = g.NewBatch(10)
+	assert.ErrorIs(t, err, ErrLeaseLost)
+	_, err = g.NewIDContext(ctx)
+	assert.ErrorIs(t, err, ErrLeaseLost)
+
+	g, err = NewGenerator(WithSIDLease(lease), WithSID(3))
+	assert.Nil(t, err)
+	_, err = g.NewID()
+	assert.Nil(t, err)
+
+	_, err = NewGenerator(WithSIDLease(nil))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestWithSIDLease_Close(t *testing.T) {
+	ctx := context.Background()
+	store := newTestMemoryLeaseStore(t)
+	lease, err := AcquireSIDLease(ctx, store, WithLeaseMaxSID(1023))
+	assert.Nil(t, err)
+
+	g, err := NewGenerator(WithSIDBits(10), WithSIDLease(lease))
+	assert.Nil(t, err)
+	_, err = g.NewID()
+	assert.Nil(t, err)
+
+	assert.Nil(t, lease.Close())
+	_, err = g.NewID()
+	assert.ErrorIs(t, err, ErrLeaseLost)
+
+	next, err := AcquireSIDLease(ctx, store)
+	assert.Nil(t, err)
+	defer next.Close()
+	assert.Equal(t, lease.SID(), next.SID())
+}
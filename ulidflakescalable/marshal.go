@@ -0,0 +1,133 @@
+package ulidflakescalable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+)
+
+// JSONNumber wraps a UlidFlake to be marshaled to JSON as a number instead of a Base32 string.
+// Note that clients decoding JSON numbers as float64 lose precision above 2^53.
+type JSONNumber struct {
+	UlidFlake
+}
+
+// MarshalJSON encodes the ID as a JSON string in Base32
+func (id ID) MarshalJSON() ([]byte, error) {
+	dst := make([]byte, 0, UlidFlakeLen+2)
+	dst = append(dst, '"')
+	dst = id.AppendString(dst)
+	return append(dst, '"'), nil
+}
+
+// UnmarshalJSON decodes a JSON string in Base32 or a JSON number into the ID
+func (id *ID) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		s, err := strconv.Unquote(string(data))
+		if err != nil {
+			return ErrInvalidULID
+		}
+		return id.UnmarshalText([]byte(s))
+	}
+	value, err := strconv.ParseInt(string(bytes.TrimSpace(data)), 10, 64)
+	if err != nil {
+		return ErrInvalidULID
+	}
+	parsed, err := FromInt(value)
+	if err != nil {
+		return err
+	}
+	*id = parsed.ID()
+	return nil
+}
+
+// MarshalText encodes the ID as Base32 text
+func (id ID) MarshalText() ([]byte, error) {
+	return id.AppendString(make([]byte, 0, UlidFlakeLen)), nil
+}
+
+// AppendText appends the Base32 text of the ID to b
+func (id ID) AppendText(b []byte) ([]byte, error) {
+	return id.AppendString(b), nil
+}
+
+// UnmarshalText decodes Base32 text into the ID
+func (id *ID) UnmarshalText(text []byte) error {
+	parsed, err := parseID(text, false)
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// MarshalBinary encodes the ID as 8 bytes in big-endian order
+func (id ID) MarshalBinary() ([]byte, error) {
+	return id.UlidFlake().bytes(), nil
+}
+
+// UnmarshalBinary decodes 8 bytes in big-endian order into the ID
+func (id *ID) UnmarshalBinary(data []byte) error {
+	if len(data) != UlidFlakeSize/8 {
+		return ErrInvalidULID
+	}
+	parsed, err := FromInt(int64(binary.BigEndian.Uint64(data)))
+	if err != nil {
+		return err
+	}
+	*id = parsed.ID()
+	return nil
+}
+
+// MarshalJSON encodes the UlidFlake as a JSON string in Base32
+func (u UlidFlake) MarshalJSON() ([]byte, error) {
+	return u.ID().MarshalJSON()
+}
+
+// UnmarshalJSON decodes a JSON string in Base32 or a JSON number into the UlidFlake
+func (u *UlidFlake) UnmarshalJSON(data []byte) error {
+	return u.decode(data, (*ID).UnmarshalJSON)
+}
+
+// MarshalText encodes the UlidFlake as Base32 text
+func (u UlidFlake) MarshalText() ([]byte, error) {
+	return u.ID().MarshalText()
+}
+
+// AppendText appends the Base32 text of the UlidFlake to b
+func (u UlidFlake) AppendText(b []byte) ([]byte, error) {
+	return u.ID().AppendText(b)
+}
+
+// UnmarshalText decodes Base32 text into the UlidFlake
+func (u *UlidFlake) UnmarshalText(text []byte) error {
+	return u.decode(text, (*ID).UnmarshalText)
+}
+
+// MarshalBinary encodes the UlidFlake as 8 bytes in big-endian order
+func (u UlidFlake) MarshalBinary() ([]byte, error) {
+	return u.bytes(), nil
+}
+
+// UnmarshalBinary decodes 8 bytes in big-endian order into the UlidFlake
+func (u *UlidFlake) UnmarshalBinary(data []byte) error {
+	return u.decode(data, (*ID).UnmarshalBinary)
+}
+
+// decode decodes data with the given ID decoder and stores the result in the UlidFlake
+func (u *UlidFlake) decode(data []byte, decoder func(*ID, []byte) error) error {
+	id := u.ID()
+	if err := decoder(&id, data); err != nil {
+		return err
+	}
+	u.value = int64(id)
+	return nil
+}
+
+// MarshalJSON encodes the UlidFlake as a JSON number
+func (n JSONNumber) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, n.Int(), 10), nil
+}
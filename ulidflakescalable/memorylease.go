@@ -0,0 +1,71 @@
+package ulidflakescalable
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// memoryLease is a lease held in a MemoryLeaseStore
+type memoryLease struct {
+	owner   string
+	expires time.Time
+}
+
+// MemoryLeaseStore is a LeaseStore for generators within one process, e.g. in tests
+type MemoryLeaseStore struct {
+	mutex  sync.Mutex
+	leases map[int64]memoryLease
+	clock  Clock
+}
+
+// NewMemoryLeaseStore creates an empty MemoryLeaseStore
+func NewMemoryLeaseStore(opts ...LeaseStoreOption) (*MemoryLeaseStore, error) {
+	cfg, err := newLeaseStoreConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &MemoryLeaseStore{leases: map[int64]memoryLease{}, clock: cfg.clock}, nil
+}
+
+// Acquire leases the lowest free or expired scalability ID to the owner
+func (s *MemoryLeaseStore) Acquire(ctx context.Context, owner string, maxSID int64, ttl time.Duration) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.clock.Now()
+	for sid := int64(MinScalability); sid <= maxSID; sid++ {
+		if lease, ok := s.leases[sid]; ok && now.Before(lease.expires) {
+			continue
+		}
+		s.leases[sid] = memoryLease{owner: owner, expires: now.Add(ttl)}
+		return sid, nil
+	}
+	return 0, fmt.Errorf("%w: all %d sids are leased", ErrSIDUnavailable, maxSID+1)
+}
+
+// Renew extends the lease of the owner, unless it expired or was taken over
+func (s *MemoryLeaseStore) Renew(ctx context.Context, owner string, sid int64, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.clock.Now()
+	lease, ok := s.leases[sid]
+	if !ok || lease.owner != owner || !now.Before(lease.expires) {
+		return ErrLeaseLost
+	}
+	s.leases[sid] = memoryLease{owner: owner, expires: now.Add(ttl)}
+	return nil
+}
+
+// Release ends the lease of the owner
+func (s *MemoryLeaseStore) Release(ctx context.Context, owner string, sid int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if lease, ok := s.leases[sid]; ok && lease.owner == owner {
+		delete(s.leases, sid)
+	}
+	return nil
+}
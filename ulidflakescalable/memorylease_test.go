@@ -0,0 +1,58 @@
+package ulidflakescalable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testLeaseStore runs the LeaseStore contract against a store expiring leases on the clock
+func testLeaseStore(t *testing.T, store LeaseStore, clock *ManualClock) {
+	ctx := context.Background()
+	ttl := 10 * time.Second
+
+	a, err := store.Acquire(ctx, "a", 2, ttl)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), a)
+	b, err := store.Acquire(ctx, "b", 2, ttl)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), b)
+	c, err := store.Acquire(ctx, "c", 2, ttl)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), c)
+
+	_, err = store.Acquire(ctx, "d", 2, ttl)
+	assert.ErrorIs(t, err, ErrSIDUnavailable)
+
+	assert.Nil(t, store.Renew(ctx, "a", a, ttl))
+	assert.ErrorIs(t, store.Renew(ctx, "b", a, ttl), ErrLeaseLost)
+
+	assert.Nil(t, store.Release(ctx, "b", a))
+	assert.Nil(t, store.Release(ctx, "b", b))
+	d, err := store.Acquire(ctx, "d", 2, ttl)
+	assert.Nil(t, err)
+	assert.Equal(t, b, d)
+	assert.ErrorIs(t, store.Renew(ctx, "b", b, ttl), ErrLeaseLost)
+
+	clock.Advance(ttl / 2)
+	assert.Nil(t, store.Renew(ctx, "a", a, ttl))
+	clock.Advance(ttl/2 + time.Millisecond)
+	assert.ErrorIs(t, store.Renew(ctx, "c", c, ttl), ErrLeaseLost)
+
+	e, err := store.Acquire(ctx, "e", 2, ttl)
+	assert.Nil(t, err)
+	assert.NotEqual(t, a, e)
+	assert.Nil(t, store.Renew(ctx, "a", a, ttl))
+}
+
+func TestMemoryLeaseStore(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store, err := NewMemoryLeaseStore(WithStoreClock(clock))
+	assert.Nil(t, err)
+	testLeaseStore(t, store, clock)
+
+	_, err = NewMemoryLeaseStore(WithStoreClock(nil))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
@@ -0,0 +1,65 @@
+package ulidflakescalable
+
+import "time"
+
+// Range is an inclusive interval of IDs, e.g. for primary-key range scans over a time window
+type Range struct {
+	Min ID
+	Max ID
+}
+
+// Contains reports whether the ID is within the range
+func (r Range) Contains(id ID) bool {
+	return r.Min <= id && id <= r.Max
+}
+
+// MinForTime returns the smallest possible ID for the millisecond of the time, against the generator's epoch,
+// with both the randomness and the scalability bits zeroed
+func (g *Generator) MinForTime(t time.Time) (ID, error) {
+	timestamp, err := generateTimestamp(t.UTC(), g.EpochTime())
+	if err != nil {
+		return Zero, err
+	}
+	return ID(g.Layout().MinForTimestamp(timestamp)), nil
+}
+
+// MaxForTime returns the largest possible ID for the millisecond of the time, against the generator's epoch,
+// with both the randomness and the scalability bits saturated
+func (g *Generator) MaxForTime(t time.Time) (ID, error) {
+	timestamp, err := generateTimestamp(t.UTC(), g.EpochTime())
+	if err != nil {
+		return Zero, err
+	}
+	return ID(g.Layout().MaxForTimestamp(timestamp)), nil
+}
+
+// TimeRange returns the inclusive range of IDs generated between from and to, against the generator's epoch
+func (g *Generator) TimeRange(from, to time.Time) (Range, error) {
+	if to.Before(from) {
+		return Range{}, ErrInvalidTimestamp
+	}
+	min, err := g.MinForTime(from)
+	if err != nil {
+		return Range{}, err
+	}
+	max, err := g.MaxForTime(to)
+	if err != nil {
+		return Range{}, err
+	}
+	return Range{Min: min, Max: max}, nil
+}
+
+// MinForTime returns the smallest possible ID for the millisecond of the time, against the default generator's epoch
+func MinForTime(t time.Time) (ID, error) {
+	return defaultGenerator.MinForTime(t)
+}
+
+// MaxForTime returns the largest possible ID for the millisecond of the time, against the default generator's epoch
+func MaxForTime(t time.Time) (ID, error) {
+	return defaultGenerator.MaxForTime(t)
+}
+
+// TimeRange returns the inclusive range of IDs generated between from and to, against the default generator's epoch
+func TimeRange(from, to time.Time) (Range, error) {
+	return defaultGenerator.TimeRange(from, to)
+}
@@ -0,0 +1,230 @@
+package ulidflakescalable
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const DefaultLeaseKeyPrefix = "ulidflake:sid:" // Default prefix of the keys of the Redis lease store
+
+const (
+	// redisRenewScript extends the lease only if the owner still holds it
+	redisRenewScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`
+
+	// redisReleaseScript deletes the lease only if the owner still holds it
+	redisReleaseScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+)
+
+// errRedisNil is the null reply of a Redis server
+var errRedisNil = errors.New("redis: nil reply")
+
+// RedisLeaseStore is a LeaseStore on a server speaking the Redis protocol (RESP), e.g. Redis, Valkey or KeyDB.
+// Each scalability ID is a key set with NX and PX, so the server expires the leases.
+type RedisLeaseStore struct {
+	mutex     sync.Mutex
+	keyPrefix string
+	dial      func(ctx context.Context) (net.Conn, error)
+	conn      net.Conn
+	reader    *bufio.Reader
+}
+
+// NewRedisLeaseStore creates a RedisLeaseStore on the server at the TCP address
+func NewRedisLeaseStore(addr string, opts ...LeaseStoreOption) (*RedisLeaseStore, error) {
+	cfg, err := newLeaseStoreConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	dial := cfg.dial
+	if dial == nil {
+		if addr == "" {
+			return nil, ErrInvalidConfig
+		}
+		dial = func(ctx context.Context) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "tcp", addr)
+		}
+	}
+	return &RedisLeaseStore{keyPrefix: cfg.keyPrefix, dial: dial}, nil
+}
+
+// Acquire leases the lowest scalability ID whose key does not exist to the owner
+func (s *RedisLeaseStore) Acquire(ctx context.Context, owner string, maxSID int64, ttl time.Duration) (int64, error) {
+	px := strconv.FormatInt(ttl.Milliseconds(), 10)
+	for sid := int64(MinScalability); sid <= maxSID; sid++ {
+		_, err := s.do(ctx, "SET", s.key(sid), owner, "NX", "PX", px)
+		if errors.Is(err, errRedisNil) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		return sid, nil
+	}
+	return 0, fmt.Errorf("%w: all %d sids are leased", ErrSIDUnavailable, maxSID+1)
+}
+
+// Renew extends the lease of the owner, unless it expired or was taken over
+func (s *RedisLeaseStore) Renew(ctx context.Context, owner string, sid int64, ttl time.Duration) error {
+	reply, err := s.do(ctx, "EVAL", redisRenewScript, "1", s.key(sid), owner, strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		return err
+	}
+	if reply != int64(1) {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Release ends the lease of the owner
+func (s *RedisLeaseStore) Release(ctx context.Context, owner string, sid int64) error {
+	_, err := s.do(ctx, "EVAL", redisReleaseScript, "1", s.key(sid), owner)
+	return err
+}
+
+// Close closes the connection to the server
+func (s *RedisLeaseStore) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn, s.reader = nil, nil
+	return err
+}
+
+// key returns the key of the scalability ID
+func (s *RedisLeaseStore) key(sid int64) string {
+	return s.keyPrefix + strconv.FormatInt(sid, 10)
+}
+
+// do sends the command and reads its reply, connecting first if needed.
+// The connection is dropped on any I/O error, to reconnect with the next command.
+func (s *RedisLeaseStore) do(ctx context.Context, args ...string) (any, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.conn, s.reader = conn, bufio.NewReader(conn)
+	}
+	deadline, _ := ctx.Deadline()
+	s.conn.SetDeadline(deadline)
+
+	reply, err := s.roundTrip(args)
+	var redisErr redisError
+	if err != nil && !errors.Is(err, errRedisNil) && !errors.As(err, &redisErr) {
+		s.conn.Close()
+		s.conn, s.reader = nil, nil
+	}
+	return reply, err
+}
+
+// roundTrip writes the command as a RESP array of bulk strings and reads the reply
+func (s *RedisLeaseStore) roundTrip(args []string) (any, error) {
+	buf := make([]byte, 0, 128)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, "\r\n"...)
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := s.conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return readRESP(s.reader)
+}
+
+// redisError is an error reply of a Redis server
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// readRESP reads a reply: a simple string, an integer, a bulk string or an array of them.
+// A null bulk string or array is returned as errRedisNil, and an error reply as redisError.
+func readRESP(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, redisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, errRedisNil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, errRedisNil
+		}
+		items := make([]any, n)
+		for i := range items {
+			items[i], err = readRESP(r)
+			if err != nil && !errors.Is(err, errRedisNil) {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+	}
+}
+
+// WithKeyPrefix sets the prefix of the keys of the Redis lease store
+func WithKeyPrefix(prefix string) LeaseStoreOption {
+	return func(cfg *leaseStoreConfig) error {
+		cfg.keyPrefix = prefix
+		return nil
+	}
+}
+
+// WithDialer sets how the Redis lease store connects to the server, e.g. over TLS or a Unix socket
+func WithDialer(dial func(ctx context.Context) (net.Conn, error)) LeaseStoreOption {
+	return func(cfg *leaseStoreConfig) error {
+		if dial == nil {
+			return ErrInvalidConfig
+		}
+		cfg.dial = dial
+		return nil
+	}
+}
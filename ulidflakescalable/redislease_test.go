@@ -0,0 +1,250 @@
+package ulidflakescalable
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedisEntry is a key of the fake Redis server
+type fakeRedisEntry struct {
+	value   string
+	expires time.Time
+}
+
+// fakeRedis is a Redis protocol server on a local port implementing the commands of RedisLeaseStore,
+// expiring the keys against its clock
+type fakeRedis struct {
+	mutex    sync.Mutex
+	listener net.Listener
+	clock    Clock
+	keys     map[string]fakeRedisEntry
+	commands []string
+}
+
+// startFakeRedis starts a fakeRedis, stopped at the end of the test
+func startFakeRedis(t *testing.T, clock Clock) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	s := &fakeRedis{listener: listener, clock: clock, keys: map[string]fakeRedisEntry{}}
+	t.Cleanup(func() { listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeRedis) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		reply, err := readRESP(r)
+		if err != nil {
+			return
+		}
+		items := reply.([]any)
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i] = item.(string)
+		}
+		if _, err := conn.Write([]byte(s.exec(args))); err != nil {
+			return
+		}
+	}
+}
+
+// get returns the value of the key, unless it expired
+func (s *fakeRedis) get(key string) (string, bool) {
+	entry, ok := s.keys[key]
+	if !ok || !s.clock.Now().Before(entry.expires) {
+		delete(s.keys, key)
+		return "", false
+	}
+	return entry.value, true
+}
+
+// exec executes the command and returns the encoded reply
+func (s *fakeRedis) exec(args []string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.commands = append(s.commands, args[0])
+	switch {
+	case args[0] == "SET" && len(args) == 6 && args[3] == "NX" && args[4] == "PX":
+		if _, ok := s.get(args[1]); ok {
+			return "$-1\r\n"
+		}
+		px, _ := strconv.Atoi(args[5])
+		s.keys[args[1]] = fakeRedisEntry{value: args[2], expires: s.clock.Now().Add(time.Duration(px) * time.Millisecond)}
+		return "+OK\r\n"
+	case args[0] == "EVAL" && args[1] == redisRenewScript:
+		if value, ok := s.get(args[3]); !ok || value != args[4] {
+			return ":0\r\n"
+		}
+		px, _ := strconv.Atoi(args[5])
+		s.keys[args[3]] = fakeRedisEntry{value: args[4], expires: s.clock.Now().Add(time.Duration(px) * time.Millisecond)}
+		return ":1\r\n"
+	case args[0] == "EVAL" && args[1] == redisReleaseScript:
+		if value, ok := s.get(args[3]); !ok || value != args[4] {
+			return ":0\r\n"
+		}
+		delete(s.keys, args[3])
+		return ":1\r\n"
+	}
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", strings.Join(args, " "))
+}
+
+func TestRedisLeaseStore(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	server := startFakeRedis(t, clock)
+	store, err := NewRedisLeaseStore(server.addr())
+	assert.Nil(t, err)
+	defer store.Close()
+
+	testLeaseStore(t, store, clock)
+
+	server.mutex.Lock()
+	_, ok := server.keys[DefaultLeaseKeyPrefix+"0"]
+	server.mutex.Unlock()
+	assert.True(t, ok)
+}
+
+func TestRedisLeaseStore_Errors(t *testing.T) {
+	ctx := context.Background()
+	server := startFakeRedis(t, SystemClock{})
+	store, err := NewRedisLeaseStore(server.addr(), WithKeyPrefix("test:"))
+	assert.Nil(t, err)
+
+	sid, err := store.Acquire(ctx, "a", MaxScalability, time.Minute)
+	assert.Nil(t, err)
+	server.mutex.Lock()
+	_, ok := server.keys["test:"+strconv.FormatInt(sid, 10)]
+	server.mutex.Unlock()
+	assert.True(t, ok)
+
+	_, err = store.do(ctx, "FLUSHALL")
+	var redisErr redisError
+	assert.ErrorAs(t, err, &redisErr)
+
+	// The connection is kept after an error reply, and redialed after a dropped connection
+	assert.Nil(t, store.Renew(ctx, "a", sid, time.Minute))
+	assert.Nil(t, store.Close())
+	assert.Nil(t, store.Renew(ctx, "a", sid, time.Minute))
+
+	server.listener.Close()
+	assert.Nil(t, store.Close())
+	assert.NotNil(t, store.Renew(ctx, "a", sid, time.Minute))
+
+	_, err = NewRedisLeaseStore("")
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = NewRedisLeaseStore(server.addr(), WithDialer(nil))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestRedisLeaseStore_SIDLease(t *testing.T) {
+	ctx := context.Background()
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	server := startFakeRedis(t, clock)
+	store, err := NewRedisLeaseStore("", WithDialer(func(ctx context.Context) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "tcp", server.addr())
+	}))
+	assert.Nil(t, err)
+	defer store.Close()
+
+	leases := make([]*SIDLease, 4)
+	for i := range leases {
+		leases[i], err = AcquireSIDLease(ctx, store, WithLeaseTTL(time.Minute), WithLeaseClock(clock))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(i), leases[i].SID())
+	}
+	clock.Advance(30 * time.Second)
+	for _, lease := range leases {
+		waitRenewed(t, lease, clock)
+	}
+	// Held past the TTL of the acquisition
+	clock.Advance(45 * time.Second)
+	for _, lease := range leases {
+		assert.Nil(t, lease.Err())
+		assert.Nil(t, lease.Close())
+	}
+
+	server.mutex.Lock()
+	assert.Contains(t, server.commands, "EVAL")
+	assert.Empty(t, server.keys)
+	server.mutex.Unlock()
+}
+
+func Test_readRESP(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    any
+		wantErr error
+	}{
+		{
+			name:  "simple string",
+			input: "+OK\r\n",
+			want:  "OK",
+		},
+		{
+			name:  "integer",
+			input: ":42\r\n",
+			want:  int64(42),
+		},
+		{
+			name:  "bulk string",
+			input: "$5\r\nhello\r\n",
+			want:  "hello",
+		},
+		{
+			name:  "array",
+			input: "*2\r\n$3\r\nGET\r\n:1\r\n",
+			want:  []any{"GET", int64(1)},
+		},
+		{
+			name:    "null bulk string",
+			input:   "$-1\r\n",
+			wantErr: errRedisNil,
+		},
+		{
+			name:    "error",
+			input:   "-ERR wrong type\r\n",
+			wantErr: redisError("ERR wrong type"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readRESP(bufio.NewReader(strings.NewReader(tt.input)))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := readRESP(bufio.NewReader(strings.NewReader("?\n")))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,163 @@
+package ulidflakescalable
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const DefaultSIDEnv = "ULIDFLAKE_SID" // Default environment variable holding the scalability ID for SIDAuto
+
+const kubernetesEnv = "KUBERNETES_SERVICE_HOST" // Environment variable Kubernetes sets in every pod
+
+// ErrSIDUnavailable is returned when no scalability ID can be derived from the host or leased
+var ErrSIDUnavailable = errors.New("sid unavailable")
+
+// SIDStrategy derives a scalability ID between 0 and maxSID, the maximum of the generator's layout
+type SIDStrategy func(maxSID int64) (int64, error)
+
+// hostname and interfaceAddrs read the host identity, and are replaced in tests
+var (
+	hostname       = os.Hostname
+	interfaceAddrs = net.InterfaceAddrs
+)
+
+// SIDFromPrivateIP derives the scalability ID from the low bits of the host's first private IPv4 address.
+// Hosts whose addresses share the low bits, e.g. in different subnets, collide.
+func SIDFromPrivateIP() SIDStrategy {
+	return func(maxSID int64) (int64, error) {
+		addrs, err := interfaceAddrs()
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrSIDUnavailable, err)
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if ip := ipNet.IP.To4(); ip != nil && ip.IsPrivate() {
+				return sidFromIP(ip, maxSID), nil
+			}
+		}
+		return 0, fmt.Errorf("%w: no private IPv4 address", ErrSIDUnavailable)
+	}
+}
+
+// SIDFromHostname derives the scalability ID from a hash of the hostname.
+// Distinct hostnames may collide, more likely so with fewer scalability ID bits.
+func SIDFromHostname() SIDStrategy {
+	return func(maxSID int64) (int64, error) {
+		name, err := hostname()
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrSIDUnavailable, err)
+		}
+		return sidFromHash(name, maxSID), nil
+	}
+}
+
+// SIDFromStatefulSet derives the scalability ID from the ordinal of a Kubernetes StatefulSet pod,
+// parsed from the hostname, which is the pod name (e.g. 3 for "web-3")
+func SIDFromStatefulSet() SIDStrategy {
+	return func(maxSID int64) (int64, error) {
+		name, err := hostname()
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrSIDUnavailable, err)
+		}
+		return sidFromOrdinal(name, maxSID)
+	}
+}
+
+// SIDFromEnv reads the scalability ID from the environment variable
+func SIDFromEnv(name string) SIDStrategy {
+	return func(maxSID int64) (int64, error) {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return 0, fmt.Errorf("%w: %s is not set", ErrSIDUnavailable, name)
+		}
+		sid, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s=%q is not an integer", ErrInvalidSID, name, value)
+		}
+		return validateSID(sid, maxSID)
+	}
+}
+
+// SIDAuto derives the scalability ID from the first strategy that succeeds, in order:
+// the DefaultSIDEnv environment variable, the StatefulSet ordinal when running in Kubernetes,
+// the private IPv4 address and the hostname. A scalability ID out of range is returned as an error
+// rather than falling through.
+func SIDAuto() SIDStrategy {
+	strategies := []SIDStrategy{SIDFromEnv(DefaultSIDEnv), inKubernetes(SIDFromStatefulSet()), SIDFromPrivateIP(), SIDFromHostname()}
+	return func(maxSID int64) (int64, error) {
+		var errs []error
+		for _, strategy := range strategies {
+			sid, err := strategy(maxSID)
+			if err == nil || !errors.Is(err, ErrSIDUnavailable) {
+				return sid, err
+			}
+			errs = append(errs, err)
+		}
+		return 0, errors.Join(errs...)
+	}
+}
+
+// inKubernetes applies the strategy only in a Kubernetes pod, so that hostnames merely ending in a number,
+// such as ip-172-31-5-200 on EC2, are not mistaken for StatefulSet pod names
+func inKubernetes(strategy SIDStrategy) SIDStrategy {
+	return func(maxSID int64) (int64, error) {
+		if os.Getenv(kubernetesEnv) == "" {
+			return 0, fmt.Errorf("%w: not running in Kubernetes", ErrSIDUnavailable)
+		}
+		return strategy(maxSID)
+	}
+}
+
+// sidFromIP returns the low bits of the IPv4 address
+func sidFromIP(ip net.IP, maxSID int64) int64 {
+	return (int64(ip[2])<<8 | int64(ip[3])) & maxSID
+}
+
+// sidFromHash returns the FNV-1a hash of the name reduced to the range of the scalability ID
+func sidFromHash(name string, maxSID int64) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64() % uint64(maxSID+1))
+}
+
+// sidFromOrdinal parses the ordinal after the last hyphen of a StatefulSet pod name
+func sidFromOrdinal(name string, maxSID int64) (int64, error) {
+	i := strings.LastIndexByte(name, '-')
+	if i < 0 {
+		return 0, fmt.Errorf("%w: %q is not a StatefulSet pod name", ErrSIDUnavailable, name)
+	}
+	ordinal, err := strconv.ParseInt(name[i+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not a StatefulSet pod name", ErrSIDUnavailable, name)
+	}
+	return validateSID(ordinal, maxSID)
+}
+
+// validateSID checks the scalability ID against the maximum of the layout
+func validateSID(sid, maxSID int64) (int64, error) {
+	if sid < MinScalability || sid > maxSID {
+		return 0, fmt.Errorf("%w: %d, want %d to %d", ErrInvalidSID, sid, MinScalability, maxSID)
+	}
+	return sid, nil
+}
+
+// WithSIDFrom derives the scalability ID with the strategy, validated against the layout,
+// when the generator is created or configured
+func WithSIDFrom(strategy SIDStrategy) Option {
+	return func(cfg *config) error {
+		if strategy == nil {
+			return ErrInvalidConfig
+		}
+		cfg.sidStrategy = strategy
+		cfg.Lease = nil
+		return nil
+	}
+}
@@ -0,0 +1,292 @@
+package ulidflakescalable
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubHost replaces the host identity for the duration of the test
+func stubHost(t *testing.T, name string, addrs ...string) {
+	t.Helper()
+	previousHostname, previousAddrs := hostname, interfaceAddrs
+	t.Cleanup(func() {
+		hostname, interfaceAddrs = previousHostname, previousAddrs
+	})
+
+	hostname = func() (string, error) {
+		if name == "" {
+			return "", errors.New("no hostname")
+		}
+		return name, nil
+	}
+	interfaceAddrs = func() ([]net.Addr, error) {
+		var result []net.Addr
+		for _, addr := range addrs {
+			ip, ipNet, err := net.ParseCIDR(addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result = append(result, &net.IPNet{IP: ip, Mask: ipNet.Mask})
+		}
+		return result, nil
+	}
+}
+
+func TestSIDFromPrivateIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		addrs   []string
+		maxSID  int64
+		want    int64
+		wantErr error
+	}{
+		{
+			name:   "low 5 bits",
+			addrs:  []string{"127.0.0.1/8", "10.0.1.37/16"},
+			maxSID: MaxScalability,
+			want:   37 & MaxScalability,
+		},
+		{
+			name:   "low 10 bits",
+			addrs:  []string{"192.168.3.200/16"},
+			maxSID: 1023,
+			want:   3<<8 | 200,
+		},
+		{
+			name:   "skips public and IPv6 addresses",
+			addrs:  []string{"fd00::1/64", "8.8.8.8/32", "172.16.0.9/12"},
+			maxSID: MaxScalability,
+			want:   9,
+		},
+		{
+			name:    "no private address",
+			addrs:   []string{"127.0.0.1/8", "8.8.8.8/32"},
+			maxSID:  MaxScalability,
+			wantErr: ErrSIDUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubHost(t, "host", tt.addrs...)
+			got, err := SIDFromPrivateIP()(tt.maxSID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSIDFromHostname(t *testing.T) {
+	stubHost(t, "api-7f9c4d-xk2lp")
+	got, err := SIDFromHostname()(MaxScalability)
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, got, int64(MinScalability))
+	assert.LessOrEqual(t, got, int64(MaxScalability))
+
+	again, err := SIDFromHostname()(MaxScalability)
+	assert.Nil(t, err)
+	assert.Equal(t, got, again)
+
+	stubHost(t, "")
+	_, err = SIDFromHostname()(MaxScalability)
+	assert.ErrorIs(t, err, ErrSIDUnavailable)
+}
+
+func TestSIDFromStatefulSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		hostname string
+		maxSID   int64
+		want     int64
+		wantErr  error
+	}{
+		{
+			name:     "ordinal",
+			hostname: "web-3",
+			maxSID:   MaxScalability,
+			want:     3,
+		},
+		{
+			name:     "hyphenated name",
+			hostname: "order-service-31",
+			maxSID:   MaxScalability,
+			want:     31,
+		},
+		{
+			name:     "ordinal above the layout",
+			hostname: "web-32",
+			maxSID:   MaxScalability,
+			wantErr:  ErrInvalidSID,
+		},
+		{
+			name:     "ordinal within a larger layout",
+			hostname: "web-32",
+			maxSID:   1023,
+			want:     32,
+		},
+		{
+			name:     "deployment pod name",
+			hostname: "web-7f9c4d-xk2lp",
+			maxSID:   MaxScalability,
+			wantErr:  ErrSIDUnavailable,
+		},
+		{
+			name:     "no hyphen",
+			hostname: "localhost",
+			maxSID:   MaxScalability,
+			wantErr:  ErrSIDUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubHost(t, tt.hostname)
+			got, err := SIDFromStatefulSet()(tt.maxSID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSIDFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		unset   bool
+		want    int64
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			value: "17",
+			want:  17,
+		},
+		{
+			name:  "surrounding spaces",
+			value: " 5\n",
+			want:  5,
+		},
+		{
+			name:    "above the layout",
+			value:   "32",
+			wantErr: ErrInvalidSID,
+		},
+		{
+			name:    "negative",
+			value:   "-1",
+			wantErr: ErrInvalidSID,
+		},
+		{
+			name:    "not an integer",
+			value:   "pod-1",
+			wantErr: ErrInvalidSID,
+		},
+		{
+			name:    "unset",
+			unset:   true,
+			wantErr: ErrSIDUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.unset {
+				t.Setenv("TEST_ULIDFLAKE_SID", tt.value)
+			}
+			got, err := SIDFromEnv("TEST_ULIDFLAKE_SID")(MaxScalability)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSIDAuto(t *testing.T) {
+	t.Setenv(kubernetesEnv, "10.96.0.1")
+	t.Setenv(DefaultSIDEnv, "12")
+	stubHost(t, "web-3", "10.0.0.9/8")
+	got, err := SIDAuto()(MaxScalability)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), got)
+
+	t.Setenv(DefaultSIDEnv, "99")
+	_, err = SIDAuto()(MaxScalability)
+	assert.ErrorIs(t, err, ErrInvalidSID)
+
+	t.Setenv(DefaultSIDEnv, "")
+	_, err = SIDAuto()(MaxScalability)
+	assert.ErrorIs(t, err, ErrInvalidSID)
+}
+
+func TestSIDAuto_Fallback(t *testing.T) {
+	t.Setenv(kubernetesEnv, "10.96.0.1")
+	stubHost(t, "web-3", "10.0.0.9/8")
+	got, err := SIDAuto()(MaxScalability)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), got)
+
+	stubHost(t, "web-7f9c4d-xk2lp", "10.0.0.9/8")
+	got, err = SIDAuto()(MaxScalability)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(9), got)
+
+	stubHost(t, "web-7f9c4d-xk2lp")
+	got, err = SIDAuto()(MaxScalability)
+	assert.Nil(t, err)
+	assert.Equal(t, sidFromHash("web-7f9c4d-xk2lp", MaxScalability), got)
+
+	stubHost(t, "")
+	_, err = SIDAuto()(MaxScalability)
+	assert.ErrorIs(t, err, ErrSIDUnavailable)
+}
+
+func TestWithSIDFrom(t *testing.T) {
+	stubHost(t, "web-20")
+
+	g, err := NewGenerator(WithSIDFrom(SIDFromStatefulSet()))
+	assert.Nil(t, err)
+	got, err := g.NewID()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(20), g.SID(got))
+
+	stubHost(t, "web-200")
+	_, err = NewGenerator(WithSIDFrom(SIDFromStatefulSet()))
+	assert.ErrorIs(t, err, ErrInvalidSID)
+
+	g, err = NewGenerator(WithSIDFrom(SIDFromStatefulSet()), WithSIDBits(10))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(200), g.generator.Config().Node)
+
+	g, err = NewGenerator(WithSIDFrom(SIDFromStatefulSet()), WithSID(4))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), g.generator.Config().Node)
+
+	_, err = NewGenerator(WithSIDFrom(nil))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestSIDAuto_OutsideKubernetes(t *testing.T) {
+	t.Setenv(kubernetesEnv, "")
+
+	// An EC2 hostname ends in a number, but is not a StatefulSet pod name
+	stubHost(t, "ip-172-31-5-200", "172.31.5.200/20")
+	got, err := SIDAuto()(MaxScalability)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(200&MaxScalability), got)
+
+	stubHost(t, "ip-172-31-5-200")
+	got, err = SIDAuto()(MaxScalability)
+	assert.Nil(t, err)
+	assert.Equal(t, sidFromHash("ip-172-31-5-200", MaxScalability), got)
+}
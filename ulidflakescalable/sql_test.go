@@ -0,0 +1,283 @@
+package ulidflakescalable
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDriver is an in-memory database/sql driver storing single-column rows per data source name
+type fakeDriver struct {
+	mutex  sync.Mutex
+	tables map[string][]driver.Value
+}
+
+var testDriver = &fakeDriver{tables: map[string][]driver.Value{}}
+
+func init() {
+	sql.Register("ulidflake-fake", testDriver)
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{driver: d, table: name}, nil
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+	table  string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return strings.Count(s.query, "?")
+}
+
+// Exec stores the single argument of an INSERT as a new row
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d := s.conn.driver
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.tables[s.conn.table] = append(d.tables[s.conn.table], args[0])
+	return driver.RowsAffected(1), nil
+}
+
+// Query returns all stored rows of the table
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	d := s.conn.driver
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	rows := append([]driver.Value(nil), d.tables[s.conn.table]...)
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct {
+	rows []driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"id"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	dest[0], r.rows = r.rows[0], r.rows[1:]
+	return nil
+}
+
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("ulidflake-fake", t.Name())
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		db.Close()
+		testDriver.mutex.Lock()
+		delete(testDriver.tables, t.Name())
+		testDriver.mutex.Unlock()
+	})
+	return db
+}
+
+func TestUlidFlake_Value(t *testing.T) {
+	db := openTestDB(t)
+	id := UlidFlake{value: 14246757444195114}
+
+	for _, arg := range []any{id, &id, SQLString{id}, NullUlidFlake{UlidFlake: id, Valid: true}, NullUlidFlake{}} {
+		_, err := db.Exec("INSERT INTO ids (id) VALUES (?)", arg)
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, []driver.Value{
+		int64(14246757444195114),
+		int64(14246757444195114),
+		"00CMXB6TAK4SA",
+		int64(14246757444195114),
+		nil,
+	}, testDriver.tables[t.Name()])
+}
+
+func TestUlidFlake_Scan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     any
+		want    UlidFlake
+		wantErr bool
+	}{
+		{
+			name: "integer",
+			src:  int64(14246757444195114),
+			want: UlidFlake{value: 14246757444195114},
+		},
+		{
+			name: "Base32 string",
+			src:  "00CMXB6TAK4SA",
+			want: UlidFlake{value: 14246757444195114},
+		},
+		{
+			name: "Base32 bytes",
+			src:  []byte("00CMXB6TAK4SA"),
+			want: UlidFlake{value: 14246757444195114},
+		},
+		{
+			name: "decimal string",
+			src:  "14246757444195114",
+			want: UlidFlake{value: 14246757444195114},
+		},
+		{
+			name: "13-digit string is decoded as Base32",
+			src:  "0123456789012",
+			want: UlidFlake{value: 38390726480134178},
+		},
+		{
+			name: "13-digit bytes are decoded as Base32",
+			src:  []byte("1234567890123"),
+			want: UlidFlake{value: 1228503247364293699},
+		},
+		{
+			name: "12-digit string is decoded as decimal",
+			src:  "123456789012",
+			want: UlidFlake{value: 123456789012},
+		},
+		{
+			name:    "negative integer",
+			src:     int64(-1),
+			wantErr: true,
+		},
+		{
+			name:    "invalid string",
+			src:     "not an id",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported type",
+			src:     1.5,
+			wantErr: true,
+		},
+		{
+			name:    "null",
+			src:     nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got UlidFlake
+			err := got.Scan(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UlidFlake.Scan() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSQLString_Scan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     any
+		want    UlidFlake
+		wantErr bool
+	}{
+		{
+			name: "Base32 string",
+			src:  "00CMXB6TAK4SA",
+			want: UlidFlake{value: 14246757444195114},
+		},
+		{
+			name: "Base32 string of digits only",
+			src:  "1424675744419",
+			want: UlidFlake{value: 1299436073180598313},
+		},
+		{
+			name: "integer",
+			src:  int64(14246757444195114),
+			want: UlidFlake{value: 14246757444195114},
+		},
+		{
+			name:    "decimal string",
+			src:     "14246757444195114",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got SQLString
+			err := got.Scan(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SQLString.Scan() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got.UlidFlake)
+			}
+		})
+	}
+}
+
+func TestUlidFlake_RoundTrip(t *testing.T) {
+	db := openTestDB(t)
+	id := UlidFlake{value: 14246757444195114}
+
+	_, err := db.Exec("INSERT INTO ids (id) VALUES (?)", id)
+	assert.Nil(t, err)
+	_, err = db.Exec("INSERT INTO ids (id) VALUES (?)", SQLString{id})
+	assert.Nil(t, err)
+	_, err = db.Exec("INSERT INTO ids (id) VALUES (?)", NullUlidFlake{})
+	assert.Nil(t, err)
+
+	rows, err := db.Query("SELECT id FROM ids")
+	assert.Nil(t, err)
+	defer rows.Close()
+
+	var got []NullUlidFlake
+	for rows.Next() {
+		var n NullUlidFlake
+		assert.Nil(t, rows.Scan(&n))
+		got = append(got, n)
+	}
+	assert.Nil(t, rows.Err())
+	assert.Equal(t, []NullUlidFlake{
+		{UlidFlake: id, Valid: true},
+		{UlidFlake: id, Valid: true},
+		{},
+	}, got)
+
+	var scanned SQLString
+	assert.Nil(t, db.QueryRow("SELECT id FROM ids").Scan(&scanned))
+	assert.Equal(t, id, scanned.UlidFlake)
+}
@@ -0,0 +1,182 @@
+package ulidflakescalable
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// tableNamePattern matches the table names accepted by NewSQLLeaseStore, optionally qualified by a schema
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// SQLLeaseStore is a LeaseStore backed by a table of any database/sql driver, created by CreateTable:
+//
+//	CREATE TABLE sid_leases (sid BIGINT PRIMARY KEY, owner VARCHAR(255) NOT NULL, expires_at BIGINT NOT NULL)
+//
+// The expiry is in Unix milliseconds of the store's clock, so the clocks of the hosts sharing
+// the table must be synchronized to well within the TTL.
+type SQLLeaseStore struct {
+	db      *sql.DB
+	clock   Clock
+	queries sqlLeaseQueries
+}
+
+// sqlLeaseQueries holds the statements of a SQLLeaseStore for its table and placeholders
+type sqlLeaseQueries struct {
+	create   string
+	list     string
+	takeOver string
+	insert   string
+	renew    string
+	release  string
+}
+
+// NewSQLLeaseStore creates a SQLLeaseStore on the table. The statements use ? placeholders
+// unless set otherwise with WithPlaceholder.
+func NewSQLLeaseStore(db *sql.DB, table string, opts ...LeaseStoreOption) (*SQLLeaseStore, error) {
+	if db == nil || !tableNamePattern.MatchString(table) {
+		return nil, ErrInvalidConfig
+	}
+	cfg, err := newLeaseStoreConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLLeaseStore{db: db, clock: cfg.clock, queries: newSQLLeaseQueries(table, cfg.placeholder)}, nil
+}
+
+// newSQLLeaseQueries formats the statements, replacing $1, $2... with the driver's placeholders
+func newSQLLeaseQueries(table string, placeholder func(n int) string) sqlLeaseQueries {
+	format := func(query string) string {
+		query = strings.ReplaceAll(query, "{table}", table)
+		for n := 4; n >= 1; n-- {
+			query = strings.ReplaceAll(query, fmt.Sprintf("$%d", n), placeholder(n))
+		}
+		return query
+	}
+	return sqlLeaseQueries{
+		create:   format("CREATE TABLE IF NOT EXISTS {table} (sid BIGINT PRIMARY KEY, owner VARCHAR(255) NOT NULL, expires_at BIGINT NOT NULL)"),
+		list:     format("SELECT sid, expires_at FROM {table} WHERE sid <= $1"),
+		takeOver: format("UPDATE {table} SET owner = $1, expires_at = $2 WHERE sid = $3 AND expires_at = $4"),
+		insert:   format("INSERT INTO {table} (sid, owner, expires_at) VALUES ($1, $2, $3)"),
+		renew:    format("UPDATE {table} SET expires_at = $1 WHERE sid = $2 AND owner = $3 AND expires_at > $4"),
+		release:  format("DELETE FROM {table} WHERE sid = $1 AND owner = $2"),
+	}
+}
+
+// CreateTable creates the table of the leases if it does not exist
+func (s *SQLLeaseStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.queries.create)
+	return err
+}
+
+// Acquire leases the lowest free or expired scalability ID to the owner. An expired lease is taken over
+// only if its expiry is unchanged since it was read, and a free one is inserted relying on the primary key,
+// so that two owners never lease the same scalability ID.
+func (s *SQLLeaseStore) Acquire(ctx context.Context, owner string, maxSID int64, ttl time.Duration) (int64, error) {
+	now := s.clock.Now()
+	expires := now.Add(ttl).UnixMilli()
+
+	leased, err := s.list(ctx, maxSID)
+	if err != nil {
+		return 0, err
+	}
+	for sid := int64(MinScalability); sid <= maxSID; sid++ {
+		previous, ok := leased[sid]
+		if ok && previous > now.UnixMilli() {
+			continue
+		}
+
+		var result sql.Result
+		if ok {
+			result, err = s.db.ExecContext(ctx, s.queries.takeOver, owner, expires, sid, previous)
+		} else {
+			result, err = s.db.ExecContext(ctx, s.queries.insert, sid, owner, expires)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			// The primary key is violated when another owner inserted the scalability ID meanwhile,
+			// which the row existing now confirms; any other error is returned
+			if ok || !s.exists(ctx, sid, maxSID) {
+				return 0, err
+			}
+			continue
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if n == 1 {
+			return sid, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: all %d sids are leased", ErrSIDUnavailable, maxSID+1)
+}
+
+// list returns the expiry of the leased scalability IDs up to maxSID
+func (s *SQLLeaseStore) list(ctx context.Context, maxSID int64) (map[int64]int64, error) {
+	rows, err := s.db.QueryContext(ctx, s.queries.list, maxSID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leased := map[int64]int64{}
+	for rows.Next() {
+		var sid, expires int64
+		if err := rows.Scan(&sid, &expires); err != nil {
+			return nil, err
+		}
+		leased[sid] = expires
+	}
+	return leased, rows.Err()
+}
+
+// exists reports whether the scalability ID is in the table, listing it anew
+func (s *SQLLeaseStore) exists(ctx context.Context, sid, maxSID int64) bool {
+	leased, err := s.list(ctx, maxSID)
+	if err != nil {
+		return false
+	}
+	_, ok := leased[sid]
+	return ok
+}
+
+// Renew extends the lease of the owner, unless it expired or was taken over
+func (s *SQLLeaseStore) Renew(ctx context.Context, owner string, sid int64, ttl time.Duration) error {
+	now := s.clock.Now()
+	result, err := s.db.ExecContext(ctx, s.queries.renew, now.Add(ttl).UnixMilli(), sid, owner, now.UnixMilli())
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Release ends the lease of the owner
+func (s *SQLLeaseStore) Release(ctx context.Context, owner string, sid int64) error {
+	_, err := s.db.ExecContext(ctx, s.queries.release, sid, owner)
+	return err
+}
+
+// WithPlaceholder sets the placeholder of the n-th argument of the SQL lease store's statements,
+// e.g. "$1" for PostgreSQL
+func WithPlaceholder(placeholder func(n int) string) LeaseStoreOption {
+	return func(cfg *leaseStoreConfig) error {
+		if placeholder == nil {
+			return ErrInvalidConfig
+		}
+		cfg.placeholder = placeholder
+		return nil
+	}
+}
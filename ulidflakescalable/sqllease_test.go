@@ -0,0 +1,230 @@
+package ulidflakescalable
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLeaseDriver is an in-memory database/sql driver interpreting the statements of SQLLeaseStore
+type fakeLeaseDriver struct {
+	mutex     sync.Mutex
+	tables    map[string]map[int64]fakeLeaseRow
+	insertErr map[string]error // error returned by the inserts into a table, if any
+}
+
+type fakeLeaseRow struct {
+	owner   string
+	expires int64
+}
+
+var testLeaseDriver = &fakeLeaseDriver{tables: map[string]map[int64]fakeLeaseRow{}, insertErr: map[string]error{}}
+
+func init() {
+	sql.Register("ulidflake-lease-fake", testLeaseDriver)
+}
+
+func (d *fakeLeaseDriver) Open(name string) (driver.Conn, error) {
+	return &fakeLeaseConn{driver: d, table: name}, nil
+}
+
+type fakeLeaseConn struct {
+	driver *fakeLeaseDriver
+	table  string
+}
+
+func (c *fakeLeaseConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeLeaseStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeLeaseConn) Close() error {
+	return nil
+}
+
+func (c *fakeLeaseConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeLeaseStmt struct {
+	conn  *fakeLeaseConn
+	query string
+}
+
+func (s *fakeLeaseStmt) Close() error {
+	return nil
+}
+
+func (s *fakeLeaseStmt) NumInput() int {
+	return -1
+}
+
+// Exec applies the CREATE, INSERT, UPDATE and DELETE statements of SQLLeaseStore
+func (s *fakeLeaseStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d := s.conn.driver
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	rows := d.tables[s.conn.table]
+	if rows == nil {
+		rows = map[int64]fakeLeaseRow{}
+		d.tables[s.conn.table] = rows
+	}
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE"):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(s.query, "INSERT"):
+		if err := d.insertErr[s.conn.table]; err != nil {
+			return nil, err
+		}
+		sid := args[0].(int64)
+		if _, ok := rows[sid]; ok {
+			return nil, errors.New("duplicate key value violates unique constraint")
+		}
+		rows[sid] = fakeLeaseRow{owner: args[1].(string), expires: args[2].(int64)}
+		return driver.RowsAffected(1), nil
+	case strings.Contains(s.query, "SET owner"):
+		sid := args[2].(int64)
+		if row, ok := rows[sid]; !ok || row.expires != args[3].(int64) {
+			return driver.RowsAffected(0), nil
+		}
+		rows[sid] = fakeLeaseRow{owner: args[0].(string), expires: args[1].(int64)}
+		return driver.RowsAffected(1), nil
+	case strings.Contains(s.query, "SET expires_at"):
+		sid := args[1].(int64)
+		row, ok := rows[sid]
+		if !ok || row.owner != args[2].(string) || row.expires <= args[3].(int64) {
+			return driver.RowsAffected(0), nil
+		}
+		rows[sid] = fakeLeaseRow{owner: row.owner, expires: args[0].(int64)}
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "DELETE"):
+		sid := args[0].(int64)
+		if row, ok := rows[sid]; ok && row.owner == args[1].(string) {
+			delete(rows, sid)
+			return driver.RowsAffected(1), nil
+		}
+		return driver.RowsAffected(0), nil
+	}
+	return nil, errors.New("unsupported statement: " + s.query)
+}
+
+// Query returns the sid and expires_at columns of the rows up to the sid argument
+func (s *fakeLeaseStmt) Query(args []driver.Value) (driver.Rows, error) {
+	d := s.conn.driver
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var values [][2]driver.Value
+	for sid, row := range d.tables[s.conn.table] {
+		if sid <= args[0].(int64) {
+			values = append(values, [2]driver.Value{sid, row.expires})
+		}
+	}
+	return &fakeLeaseRows{rows: values}, nil
+}
+
+type fakeLeaseRows struct {
+	rows [][2]driver.Value
+}
+
+func (r *fakeLeaseRows) Columns() []string {
+	return []string{"sid", "expires_at"}
+}
+
+func (r *fakeLeaseRows) Close() error {
+	return nil
+}
+
+func (r *fakeLeaseRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	dest[0], dest[1] = r.rows[0][0], r.rows[0][1]
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func openTestLeaseDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("ulidflake-lease-fake", t.Name())
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		db.Close()
+		testLeaseDriver.mutex.Lock()
+		delete(testLeaseDriver.tables, t.Name())
+		delete(testLeaseDriver.insertErr, t.Name())
+		testLeaseDriver.mutex.Unlock()
+	})
+	return db
+}
+
+func TestSQLLeaseStore(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store, err := NewSQLLeaseStore(openTestLeaseDB(t), "sid_leases", WithStoreClock(clock))
+	assert.Nil(t, err)
+	assert.Nil(t, store.CreateTable(context.Background()))
+	testLeaseStore(t, store, clock)
+}
+
+func TestSQLLeaseStore_Concurrent(t *testing.T) {
+	db := openTestLeaseDB(t)
+	var wg sync.WaitGroup
+	sids := make([]int64, 32)
+	for i := range sids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store, err := NewSQLLeaseStore(db, "sid_leases")
+			assert.Nil(t, err)
+			sids[i], err = store.Acquire(context.Background(), "owner-"+strconv.Itoa(i), MaxScalability, time.Minute)
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[int64]bool{}
+	for _, sid := range sids {
+		assert.False(t, seen[sid], "sid %d leased twice", sid)
+		seen[sid] = true
+	}
+}
+
+func TestSQLLeaseStore_AcquireError(t *testing.T) {
+	store, err := NewSQLLeaseStore(openTestLeaseDB(t), "sid_leases")
+	assert.Nil(t, err)
+	errDisk := errors.New("disk full")
+	testLeaseDriver.mutex.Lock()
+	testLeaseDriver.insertErr[t.Name()] = errDisk
+	testLeaseDriver.mutex.Unlock()
+
+	_, err = store.Acquire(context.Background(), "owner", MaxScalability, time.Minute)
+	assert.ErrorIs(t, err, errDisk)
+	assert.NotErrorIs(t, err, ErrSIDUnavailable)
+}
+
+func TestNewSQLLeaseStore(t *testing.T) {
+	db := openTestLeaseDB(t)
+
+	store, err := NewSQLLeaseStore(db, "ids.sid_leases", WithPlaceholder(func(n int) string { return "$" + strconv.Itoa(n) }))
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE ids.sid_leases SET owner = $1, expires_at = $2 WHERE sid = $3 AND expires_at = $4", store.queries.takeOver)
+
+	store, err = NewSQLLeaseStore(db, "sid_leases")
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE sid_leases SET owner = ?, expires_at = ? WHERE sid = ? AND expires_at = ?", store.queries.takeOver)
+
+	_, err = NewSQLLeaseStore(db, "sid_leases; DROP TABLE users")
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = NewSQLLeaseStore(nil, "sid_leases")
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = NewSQLLeaseStore(db, "sid_leases", WithPlaceholder(nil))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
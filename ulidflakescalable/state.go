@@ -0,0 +1,46 @@
+package ulidflakescalable
+
+import (
+	"time"
+
+	"github.com/abailinrun/ulid-flake-go/flake"
+)
+
+const DefaultReserveAhead = flake.DefaultReserveAhead // Default time the persisted high-water mark is reserved ahead of the generated timestamps
+
+// StateStore persists the high-water mark of a generator: a time all the Ulid-Flakes it issued were generated before.
+// A generator with a StateStore treats the loaded mark as its previous timestamp, so after a restart it does not issue
+// Ulid-Flakes sorting before or duplicating the ones issued before, even if the clock was set back meanwhile.
+type StateStore = flake.StateStore
+
+// FileStateStore is a StateStore persisting the high-water mark as Unix milliseconds in a file.
+// It replaces the file atomically and syncs it to disk on every save.
+type FileStateStore = flake.FileStateStore
+
+// NewFileStateStore creates a FileStateStore on the file at the path
+func NewFileStateStore(path string) *FileStateStore {
+	return flake.NewFileStateStore(path)
+}
+
+// WithStateStore sets the store persisting the high-water mark, which is loaded when the generator is created
+func WithStateStore(store StateStore) Option {
+	return func(cfg *config) error {
+		if store == nil {
+			return ErrInvalidConfig
+		}
+		cfg.StateStore = store
+		return nil
+	}
+}
+
+// WithReserveAhead sets how far ahead of the generated timestamps the high-water mark is persisted.
+// A longer reservation saves less often, but waits longer when the generator is created after a restart.
+func WithReserveAhead(d time.Duration) Option {
+	return func(cfg *config) error {
+		if d < time.Millisecond {
+			return ErrInvalidConfig
+		}
+		cfg.ReserveAhead = d
+		return nil
+	}
+}
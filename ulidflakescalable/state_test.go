@@ -0,0 +1,231 @@
+package ulidflakescalable
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStateStore counts the saves to the underlying store, failing them with err if set
+type countingStateStore struct {
+	StateStore
+	saves int
+	err   error
+}
+
+func (s *countingStateStore) Save(mark time.Time) error {
+	s.saves++
+	if s.err != nil {
+		return s.err
+	}
+	return s.StateStore.Save(mark)
+}
+
+func TestGenerator_StateStoreCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ulidflake.state")
+	assert.Nil(t, os.WriteFile(path, []byte("garbage"), 0o644))
+	_, err := NewGenerator(WithStateStore(NewFileStateStore(path)))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestGenerator_StateStore(t *testing.T) {
+	now := time.Date(2024, 7, 1, 0, 0, 1, 0, time.UTC)
+	clock := NewManualClock(now)
+	store := &countingStateStore{StateStore: NewFileStateStore(filepath.Join(t.TempDir(), "state"))}
+	g, err := NewGenerator(WithClock(clock), WithStateStore(store), WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+
+	last, err := g.NewID()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, store.saves)
+	mark, err := store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(DefaultReserveAhead), mark)
+
+	for i := 0; i < 100; i++ {
+		clock.Advance(5 * time.Millisecond)
+		last, err = g.NewID()
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 1, store.saves)
+
+	clock.Advance(DefaultReserveAhead)
+	last, err = g.NewID()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, store.saves)
+
+	// A restart after the clock was set back refuses to generate until the clock passes the mark
+	mark, err = store.Load()
+	assert.Nil(t, err)
+	clock.Rewind(time.Hour)
+	restarted, err := NewGenerator(WithClock(clock), WithStateStore(store), WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+	_, err = restarted.NewID()
+	var regressionErr *ClockRegressionError
+	assert.ErrorAs(t, err, &regressionErr)
+
+	// or waits for it
+	got, err := restarted.NewIDContext(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, last.Less(got))
+	assert.False(t, restarted.Time(got).Before(mark))
+
+	_, err = NewGenerator(WithStateStore(nil))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = NewGenerator(WithReserveAhead(0))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestGenerator_StateStoreSameMillisecond(t *testing.T) {
+	now := time.Date(2024, 7, 1, 0, 0, 1, 0, time.UTC)
+	clock := NewManualClock(now)
+	store := NewFileStateStore(filepath.Join(t.TempDir(), "state"))
+	assert.Nil(t, store.Save(now))
+
+	g, err := NewGenerator(WithClock(clock), WithStateStore(store), WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+	got, err := g.NewID()
+	assert.Nil(t, err)
+	assert.Equal(t, now, g.Time(got))
+}
+
+func TestGenerator_StateStoreRestart(t *testing.T) {
+	now := time.Date(2024, 7, 1, 0, 0, 1, 0, time.UTC)
+	reserveAhead := 50 * time.Millisecond
+	generators := []struct {
+		name  string
+		newID func(opts ...Option) (func() (ID, error), error)
+	}{
+		{
+			name: "generator",
+			newID: func(opts ...Option) (func() (ID, error), error) {
+				g, err := NewGenerator(opts...)
+				if err != nil {
+					return nil, err
+				}
+				return g.NewID, nil
+			},
+		},
+		{
+			name: "concurrent generator",
+			newID: func(opts ...Option) (func() (ID, error), error) {
+				g, err := NewConcurrentGenerator(opts...)
+				if err != nil {
+					return nil, err
+				}
+				return g.NewID, nil
+			},
+		},
+	}
+	tests := []struct {
+		name      string
+		policy    RegressionPolicy
+		restart   time.Duration
+		wantClock time.Time
+		wantErr   error
+	}{
+		{
+			name:      "wait out the reserved window",
+			policy:    RegressionFail,
+			restart:   10 * time.Millisecond,
+			wantClock: now.Add(reserveAhead),
+		},
+		{
+			name:      "clock set back: error",
+			policy:    RegressionFail,
+			restart:   -20 * time.Millisecond,
+			wantClock: now.Add(-20 * time.Millisecond),
+			wantErr:   ErrInvalidTimestamp,
+		},
+		{
+			name:      "clock set back: wait until the clock reaches the mark",
+			policy:    RegressionWait,
+			restart:   -20 * time.Millisecond,
+			wantClock: now.Add(reserveAhead),
+		},
+		{
+			name:      "clock set back: issue from the mark",
+			policy:    RegressionReuse,
+			restart:   -20 * time.Millisecond,
+			wantClock: now.Add(-20 * time.Millisecond),
+		},
+	}
+	for _, gen := range generators {
+		for _, tt := range tests {
+			t.Run(gen.name+"/"+tt.name, func(t *testing.T) {
+				clock := NewManualClock(now)
+				store := NewFileStateStore(filepath.Join(t.TempDir(), "state"))
+				opts := []Option{WithClock(clock), WithStateStore(store), WithReserveAhead(reserveAhead),
+					WithRegressionPolicy(tt.policy), WithEntropySource(constantReader(1))}
+				newID, err := gen.newID(opts...)
+				require.Nil(t, err)
+				last, err := newID()
+				require.Nil(t, err)
+
+				// restart before the clock passes the mark
+				clock.Set(now.Add(tt.restart))
+				newID, err = gen.newID(opts...)
+				require.Nil(t, err)
+				for i := 0; i < 100; i++ {
+					got, err := newID()
+					if tt.wantErr != nil {
+						assert.ErrorIs(t, err, tt.wantErr)
+						break
+					}
+					require.Nil(t, err)
+					assert.Equal(t, now.Add(reserveAhead).Sub(time.Unix(DefaultEpochSec, 0)).Milliseconds(), got.Timestamp())
+					assert.True(t, last.Less(got))
+					last = got
+				}
+				assert.Equal(t, tt.wantClock, clock.Now())
+			})
+		}
+	}
+}
+
+func TestGenerator_StateStoreSaveError(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC))
+	store := &countingStateStore{StateStore: NewFileStateStore(filepath.Join(t.TempDir(), "state")), err: errors.New("disk full")}
+	g, err := NewGenerator(WithClock(clock), WithStateStore(store), WithReserveAhead(time.Minute))
+	assert.Nil(t, err)
+
+	_, err = g.NewID()
+	assert.ErrorIs(t, err, store.err)
+	_, err = g.NewBatch(10)
+	assert.ErrorIs(t, err, store.err)
+	assert.Equal(t, Stats{}, g.Stats())
+
+	store.err = nil
+	_, err = g.NewBatch(10)
+	assert.Nil(t, err)
+	mark, err := store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, clock.Now().Add(time.Minute), mark)
+}
+
+func TestConcurrentGenerator_StateStore(t *testing.T) {
+	now := time.Date(2024, 7, 1, 0, 0, 1, 0, time.UTC)
+	clock := NewManualClock(now)
+	store := &countingStateStore{StateStore: NewFileStateStore(filepath.Join(t.TempDir(), "state"))}
+	g, err := NewConcurrentGenerator(WithClock(clock), WithStateStore(store), WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+
+	last, err := g.NewID()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, store.saves)
+
+	clock.Rewind(time.Hour)
+	restarted, err := NewConcurrentGenerator(WithClock(clock), WithStateStore(store), WithRegressionPolicy(RegressionReuse), WithOverflowPolicy(OverflowWait))
+	assert.Nil(t, err)
+	got, err := restarted.NewID()
+	assert.Nil(t, err)
+	assert.True(t, last.Less(got))
+	assert.Equal(t, now.Add(DefaultReserveAhead), restarted.generator.Config().Epoch.Add(time.Duration(got.Timestamp())*time.Millisecond))
+	assert.Equal(t, 2, store.saves)
+}
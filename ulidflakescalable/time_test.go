@@ -0,0 +1,215 @@
+package ulidflakescalable
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerator_Time(t *testing.T) {
+	tests := []struct {
+		name          string
+		epoch         time.Time
+		id            ID
+		wantTime      time.Time
+		wantUnixMilli int64
+	}{
+		{
+			name:          "minimal value with the default epoch",
+			epoch:         time.Unix(DefaultEpochSec, 0),
+			id:            Zero,
+			wantTime:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantUnixMilli: DefaultEpochSec * 1000,
+		},
+		{
+			name:          "one second and one millisecond with the default epoch",
+			epoch:         time.Unix(DefaultEpochSec, 0),
+			id:            ID(1001 << 20),
+			wantTime:      time.Date(2024, 1, 1, 0, 0, 1, int(time.Millisecond), time.UTC),
+			wantUnixMilli: DefaultEpochSec*1000 + 1001,
+		},
+		{
+			name:          "custom epoch",
+			epoch:         time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			id:            ID(1001<<20 | MaxRandomness),
+			wantTime:      time.Date(2020, 1, 1, 0, 0, 1, int(time.Millisecond), time.UTC),
+			wantUnixMilli: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli() + 1001,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGenerator(WithEpochTime(tt.epoch))
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantTime, g.Time(tt.id))
+			assert.Equal(t, tt.wantUnixMilli, g.UnixMilli(tt.id))
+			assert.Equal(t, time.Second, g.Age(tt.id, tt.wantTime.Add(time.Second)))
+		})
+	}
+}
+
+func TestGenerator_TimeOfNew(t *testing.T) {
+	now := time.Date(2030, 6, 15, 12, 30, 45, int(123*time.Millisecond), time.UTC)
+	g, err := NewGenerator(WithEpochTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)), WithClock(NewManualClock(now)))
+	assert.Nil(t, err)
+
+	id, err := g.NewID()
+	assert.Nil(t, err)
+	assert.Equal(t, now, g.Time(id))
+	assert.Equal(t, now.UnixMilli(), g.UnixMilli(id))
+	assert.Equal(t, time.Duration(0), g.Age(id, now))
+}
+
+func TestID_Time(t *testing.T) {
+	id := ID(1001 << 20)
+	want := time.Date(2024, 1, 1, 0, 0, 1, int(time.Millisecond), time.UTC)
+	assert.Equal(t, want, id.Time())
+	assert.Equal(t, want.UnixMilli(), id.UnixMilli())
+	assert.Equal(t, time.Minute, id.Age(want.Add(time.Minute)))
+
+	u := id.UlidFlake()
+	assert.Equal(t, want, u.Time())
+	assert.Equal(t, want.UnixMilli(), u.UnixMilli())
+	assert.Equal(t, time.Minute, u.Age(want.Add(time.Minute)))
+}
+
+func TestGenerator_FromTime(t *testing.T) {
+	epoch := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 3, 1, 12, 0, 0, int(250*time.Millisecond), time.UTC)
+	g, err := NewGenerator(WithEpochTime(epoch), WithClock(NewManualClock(now)))
+	assert.Nil(t, err)
+
+	generated, err := g.New()
+	assert.Nil(t, err)
+
+	got, err := g.FromTime(now)
+	assert.Nil(t, err)
+	assert.Equal(t, generated.Timestamp(), got.Timestamp())
+	assert.Equal(t, now, g.Time(got.ID()))
+
+	got, err = g.FromUnixMilli(now.UnixMilli())
+	assert.Nil(t, err)
+	assert.Equal(t, generated.Timestamp(), got.Timestamp())
+
+	got, err = g.FromTime(now, WithFixedRandomness(MaxRandomness))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(MaxRandomness), got.Randomness())
+
+	_, err = g.FromTime(now, WithFixedRandomness(MaxRandomness+1))
+	assert.ErrorIs(t, err, ErrInvalidRandomness)
+
+	got, err = g.FromTime(now)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), got.SID())
+
+	g, err = NewGenerator(WithEpochTime(epoch), WithSID(3))
+	assert.Nil(t, err)
+	got, err = g.FromTime(now)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), got.SID())
+
+	got, err = g.FromTime(now, WithFixedSID(MaxScalability))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(MaxScalability), got.SID())
+
+	_, err = g.FromTime(now, WithFixedSID(MaxScalability+1))
+	assert.ErrorIs(t, err, ErrInvalidSID)
+
+	g, err = NewGenerator(WithEpochTime(epoch), WithSIDBits(10))
+	assert.Nil(t, err)
+	got, err = g.FromTime(now, WithFixedRandomness(1023), WithFixedSID(1023))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1023), g.Randomness(got.ID()))
+	assert.Equal(t, int64(1023), g.SID(got.ID()))
+
+	_, err = g.FromTime(now, WithFixedRandomness(1024))
+	assert.ErrorIs(t, err, ErrInvalidRandomness)
+
+	_, err = g.FromTime(now, WithFixedSID(1024))
+	assert.ErrorIs(t, err, ErrInvalidSID)
+
+	_, err = g.FromTime(epoch.Add(-time.Millisecond))
+	assert.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestFromUnixMilli(t *testing.T) {
+	type args struct {
+		unixMilli int64
+		opts      []FromOption
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    int64
+		wantErr bool
+	}{
+		{
+			name: "default epoch",
+			args: args{
+				unixMilli: DefaultEpochSec * 1000,
+				opts:      []FromOption{WithFixedRandomness(0)},
+			},
+			want:    0,
+			wantErr: false,
+		},
+		{
+			name: "millisecond precision",
+			args: args{
+				unixMilli: DefaultEpochSec*1000 + 1001,
+				opts:      []FromOption{WithFixedRandomness(1)},
+			},
+			want:    1001<<20 | 1<<5,
+			wantErr: false,
+		},
+		{
+			name: "maximal value",
+			args: args{
+				unixMilli: DefaultEpochSec*1000 + MaxTimestamp,
+				opts:      []FromOption{WithFixedRandomness(MaxRandomness), WithFixedSID(MaxScalability)},
+			},
+			want:    MaxInt,
+			wantErr: false,
+		},
+		{
+			name: "before the epoch",
+			args: args{
+				unixMilli: DefaultEpochSec*1000 - 1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "after the maximal timestamp",
+			args: args{
+				unixMilli: DefaultEpochSec*1000 + MaxTimestamp + 1,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromUnixMilli(tt.args.unixMilli, tt.args.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FromUnixMilli() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got.Int())
+			}
+		})
+	}
+}
+
+func TestFromUnixEpochTime_CustomEpoch(t *testing.T) {
+	epoch := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Nil(t, SetConfig(WithEpochTime(epoch)))
+	t.Cleanup(func() { SetConfig() })
+
+	got, err := FromUnixEpochTime(epoch.Unix() + 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1000), got.Timestamp())
+
+	got, err = FromTime(epoch.Add(time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1000), got.Timestamp())
+	assert.Equal(t, epoch.Add(time.Second), got.Time())
+}